@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// dishFlags are the flags of the DishInfo fields, shared by dish create and dish update.
+type dishFlags struct {
+	name        *string
+	price       *int
+	description *string
+	composition *string
+	author      *int
+	photoURL    *string
+	category    *string
+	allergens   *string
+}
+
+// dishFlagFields maps the flags of dishFlags to DishInfo field names for the update mask.
+var dishFlagFields = map[string]string{
+	"name":        "name",
+	"price":       "price",
+	"description": "description",
+	"composition": "composition",
+	"author":      "author",
+	"photo-url":   "photo_url",
+	"category":    "category",
+	"allergens":   "allergens",
+}
+
+func addDishFlags(flags *flag.FlagSet) *dishFlags {
+	return &dishFlags{
+		name:        flags.String("name", "", "dish name"),
+		price:       flags.Int("price", 0, "price"),
+		description: flags.String("description", "", "description"),
+		composition: flags.String("composition", "", "composition"),
+		author:      flags.Int("author", 0, "id of the author"),
+		photoURL:    flags.String("photo-url", "", "photo URL"),
+		category:    flags.String("category", "", "category"),
+		allergens:   flags.String("allergens", "", "comma separated allergens"),
+	}
+}
+
+func (f *dishFlags) info() *desc.DishInfo {
+	info := &desc.DishInfo{
+		Name:        *f.name,
+		Price:       int32(*f.price),
+		Description: *f.description,
+		Composition: *f.composition,
+		Author:      int32(*f.author),
+		PhotoUrl:    *f.photoURL,
+		Category:    *f.category,
+	}
+	for _, allergen := range strings.Split(*f.allergens, ",") {
+		if allergen = strings.TrimSpace(allergen); allergen != "" {
+			info.Allergens = append(info.Allergens, allergen)
+		}
+	}
+	return info
+}
+
+// parseID parses the single ID argument of a command.
+func parseID(flags *flag.FlagSet, args []string) int32 {
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	id, err := strconv.ParseInt(flags.Arg(0), 10, 32)
+	if err != nil {
+		fmt.Fprintf(flags.Output(), "invalid id %q\n", flags.Arg(0))
+		os.Exit(2)
+	}
+	return int32(id)
+}
+
+func idUsage(flags *flag.FlagSet, usage string) {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: dishctl %s\n", usage)
+		flags.PrintDefaults()
+	}
+}
+
+func runDishCreate(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("dish create", flag.ExitOnError)
+	dish := addDishFlags(flags)
+	flags.Parse(args)
+
+	res, err := client.Create(ctx, &desc.CreateRequest{Info: dish.info()})
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\n%d\n", res.GetId())
+	})
+}
+
+func runDishGet(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("dish get", flag.ExitOnError)
+	showDeleted := flags.Bool("show-deleted", false, "show a deleted dish, managers only")
+	idUsage(flags, "dish get [-show-deleted] ID")
+	id := parseID(flags, args)
+
+	res, err := client.Get(ctx, &desc.GetRequest{Id: id, ShowDeleted: *showDeleted})
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		dish := res.GetNote()
+		info := dish.GetInfo()
+		rows := [][2]string{
+			{"ID", strconv.Itoa(int(dish.GetId()))},
+			{"NAME", info.GetName()},
+			{"PRICE", strconv.Itoa(int(info.GetPrice()))},
+			{"DISCOUNTED PRICE", discountedPrice(dish)},
+			{"DESCRIPTION", info.GetDescription()},
+			{"COMPOSITION", info.GetComposition()},
+			{"AUTHOR", strconv.Itoa(int(info.GetAuthor()))},
+			{"PHOTO URL", info.GetPhotoUrl()},
+			{"CATEGORY", info.GetCategory()},
+			{"ALLERGENS", strings.Join(info.GetAllergens(), ", ")},
+			{"CREATED AT", formatTime(dish.GetCreatedAt())},
+			{"UPDATED AT", formatTime(dish.GetUpdatedAt())},
+			{"VERSION", strconv.Itoa(int(dish.GetVersion()))},
+			{"DELETED AT", formatTime(dish.GetDeletedAt())},
+		}
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s\t%s\n", row[0], row[1])
+		}
+	})
+}
+
+func discountedPrice(dish *desc.Dish) string {
+	if dish.GetDiscountedPrice() == nil {
+		return ""
+	}
+	return strconv.Itoa(int(dish.GetDiscountedPrice().GetValue()))
+}
+
+func runDishList(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("dish list", flag.ExitOnError)
+	showDeleted := flags.Bool("show-deleted", false, "include deleted dishes, managers only")
+	favouriteOf := flags.Int("favourite-of", 0, "list only the favourites of this person")
+	flags.Parse(args)
+
+	req := &desc.ListRequest{ShowDeleted: *showDeleted}
+	if *favouriteOf != 0 {
+		req.FavouriteOf = wrapperspb.Int32(int32(*favouriteOf))
+	}
+	res, err := client.List(ctx, req)
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\tNAME\tPRICE\tCATEGORY\tAUTHOR\tVERSION\tDELETED AT\n")
+		for _, dish := range res.GetDishes() {
+			info := dish.GetInfo()
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%d\t%d\t%s\n", dish.GetId(), info.GetName(), info.GetPrice(),
+				info.GetCategory(), info.GetAuthor(), dish.GetVersion(), formatTime(dish.GetDeletedAt()))
+		}
+	})
+}
+
+func runDishUpdate(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("dish update", flag.ExitOnError)
+	dish := addDishFlags(flags)
+	expectedVersion := flags.Int("expected-version", 0, "reject the update unless the dish still has this version")
+	idUsage(flags, "dish update [flags] ID, only the given fields are changed")
+	id := parseID(flags, args)
+
+	mask := &fieldmaskpb.FieldMask{}
+	flags.Visit(func(f *flag.Flag) {
+		if field, ok := dishFlagFields[f.Name]; ok {
+			mask.Paths = append(mask.Paths, field)
+		}
+	})
+	if len(mask.GetPaths()) == 0 {
+		return fmt.Errorf("nothing to update, set at least one dish field")
+	}
+	req := &desc.UpdateRequest{Id: id, Dish: dish.info(), UpdateMask: mask}
+	if *expectedVersion != 0 {
+		req.ExpectedVersion = wrapperspb.Int32(int32(*expectedVersion))
+	}
+
+	res, err := client.Update(ctx, req)
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "dish %d updated\n", id)
+	})
+}
+
+func runDishDelete(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("dish delete", flag.ExitOnError)
+	idUsage(flags, "dish delete ID")
+	id := parseID(flags, args)
+
+	res, err := client.Delete(ctx, &desc.DeleteRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "dish %d deleted\n", id)
+	})
+}
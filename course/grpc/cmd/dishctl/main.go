@@ -0,0 +1,141 @@
+// dishctl manages the dishes and persons of grpc_server from the command line.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAddress = "localhost:50051"
+	defaultTimeout = 30 * time.Second
+	// authorizationMetadata carries the token printed by person login as "Bearer <token>".
+	authorizationMetadata = "authorization"
+)
+
+// command is a dishctl subcommand, run gets the arguments after the command name.
+// Names of grouped commands have two words, such as "dish get".
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, client desc.DishV1Client, args []string) error
+}
+
+var commands = []command{
+	{"dish create", "create a dish", runDishCreate},
+	{"dish get", "show a dish", runDishGet},
+	{"dish list", "list dishes", runDishList},
+	{"dish update", "change the given fields of a dish", runDishUpdate},
+	{"dish delete", "delete a dish", runDishDelete},
+	{"person create", "register a person", runPersonCreate},
+	{"person login", "check a password and print the token to use as -token", runPersonLogin},
+	{"person change-position", "change the position of a person, managers only", runPersonChangePosition},
+	{"import", "create dishes from a CSV or XLSX spreadsheet", runImport},
+	{"export", "write dishes to a CSV or XLSX spreadsheet", runExport},
+	{"print", "render the menu of available dishes as PDF or HTML", runPrint},
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func usage(flags *flag.FlagSet) {
+	fmt.Fprintf(flags.Output(), "usage: dishctl [flags] <command> [command flags] [args]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(flags.Output(), "  %-24s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(flags.Output(), "\nflags:\n")
+	flags.PrintDefaults()
+}
+
+// findCommand matches the first two arguments, then the first one, and returns the rest.
+func findCommand(args []string) (*command, []string) {
+	for words := 2; words >= 1; words-- {
+		if len(args) < words {
+			continue
+		}
+		name := strings.Join(args[:words], " ")
+		for i := range commands {
+			if commands[i].name == name {
+				return &commands[i], args[words:]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// describeError spells out the field violations of an InvalidArgument status.
+func describeError(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+	var b strings.Builder
+	b.WriteString(st.Message())
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fmt.Fprintf(&b, "\n  %s: %s", violation.GetField(), violation.GetDescription())
+			}
+		}
+	}
+	return b.String()
+}
+
+func main() {
+	flags := flag.NewFlagSet("dishctl", flag.ExitOnError)
+	address := flags.String("addr", envOr("DISHCTL_ADDR", defaultAddress), "grpc_server address, defaults to $DISHCTL_ADDR")
+	token := flags.String("token", os.Getenv("DISHCTL_TOKEN"), "auth token printed by person login, defaults to $DISHCTL_TOKEN")
+	timeout := flags.Duration("timeout", defaultTimeout, "timeout of the whole command")
+	flags.StringVar(&outputFormat, "output", envOr("DISHCTL_OUTPUT", outputTable), "output of dish and person commands: table, json or yaml, defaults to $DISHCTL_OUTPUT")
+	flags.Usage = func() { usage(flags) }
+	flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if outputFormat != outputTable && outputFormat != outputJSON && outputFormat != outputYAML {
+		fmt.Fprintf(os.Stderr, "dishctl: unsupported output %q, expected table, json or yaml\n", outputFormat)
+		os.Exit(2)
+	}
+
+	cmd, args := findCommand(flags.Args())
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "dishctl: unknown command %q\n\n", strings.Join(flags.Args(), " "))
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := grpc.NewClient(*address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dishctl: failed to connect to %s: %v\n", *address, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if *token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationMetadata, "Bearer "+*token)
+	}
+
+	if err = cmd.run(ctx, desc.NewDishV1Client(conn), args); err != nil {
+		fmt.Fprintf(os.Stderr, "dishctl %s: %s\n", cmd.name, describeError(err))
+		cancel()
+		conn.Close()
+		os.Exit(1)
+	}
+}
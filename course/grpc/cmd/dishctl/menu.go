@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/menuprint"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+func runPrint(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("print", flag.ExitOnError)
+	format := flags.String("format", "", "pdf or html, defaults to the extension of -o or pdf")
+	output := flags.String("o", "-", "output file, - writes to stdout")
+	templates := flags.String("templates", os.Getenv("MENU_TEMPLATE_DIR"), "directory with "+menuprint.HTMLTemplate+" and "+menuprint.PDFTemplate+", defaults to $MENU_TEMPLATE_DIR or the built-in templates")
+	photos := flags.Bool("photos", false, "print dish photos")
+	title := flags.String("title", menuprint.DefaultTitle, "menu title")
+	flags.Parse(args)
+
+	menuFormat := *format
+	if menuFormat == "" {
+		menuFormat = menuprint.FormatPDF
+		if ext := strings.TrimPrefix(strings.ToLower(path.Ext(*output)), "."); ext == menuprint.FormatHTML {
+			menuFormat = ext
+		}
+	}
+	if _, ok := menuprint.ContentTypes[menuFormat]; !ok {
+		return fmt.Errorf("unsupported format %q, expected pdf or html", menuFormat)
+	}
+	renderer, err := menuprint.NewRenderer(*templates)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.List(ctx, &desc.ListRequest{})
+	if err != nil {
+		return err
+	}
+	menu := menuprint.Build(*title, res.GetDishes(), time.Now())
+	menu.Photos = *photos
+	data, err := renderer.Render(ctx, menuFormat, menu)
+	if err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats of the dish and person commands.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormat = outputTable
+
+// printResult writes res as JSON or YAML with the proto field names, or as the table written by table.
+func printResult(res proto.Message, table func(w io.Writer)) error {
+	switch outputFormat {
+	case outputJSON, outputYAML:
+		data, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true, EmitUnpopulated: true}.Marshal(res)
+		if err != nil {
+			return err
+		}
+		if outputFormat == outputJSON {
+			_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+			return err
+		}
+		return writeYAML(os.Stdout, data)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+// writeYAML converts JSON to block style YAML, keeping the field order of the JSON.
+func writeYAML(w io.Writer, data []byte) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetStyle drops the flow and quoting styles parsed from JSON, the encoder quotes where YAML needs it.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+func formatTime(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.AsTime().Local().Format(time.RFC3339)
+}
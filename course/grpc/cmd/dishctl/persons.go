@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"io"
+	"os"
+	"strings"
+)
+
+// readPassword returns the -password flag or, to keep it out of the shell history, the first line of stdin.
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runPersonCreate(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("person create", flag.ExitOnError)
+	login := flags.String("login", "", "login")
+	password := flags.String("password", "", "password, read from stdin when omitted")
+	position := flags.String("position", "", "position")
+	flags.Parse(args)
+
+	pass, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	res, err := client.CreatePerson(ctx, &desc.CreatePersonReqest{Login: *login, Password: pass, Position: *position})
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\n%d\n", res.GetId())
+	})
+}
+
+func runPersonLogin(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("person login", flag.ExitOnError)
+	login := flags.String("login", "", "login")
+	password := flags.String("password", "", "password, read from stdin when omitted")
+	flags.Parse(args)
+
+	pass, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	res, err := client.LogInPerson(ctx, &desc.LogInPersonRequest{Login: *login, Password: pass})
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\tPOSITION\tEXPIRES AT\tTOKEN\n%d\t%s\t%s\t%s\n",
+			res.GetId(), res.GetPosition(), formatTime(res.GetTokenExpiresAt()), res.GetToken())
+	})
+}
+
+func runPersonChangePosition(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("person change-position", flag.ExitOnError)
+	position := flags.String("position", "", "new position")
+	idUsage(flags, "person change-position -position POSITION ID")
+	id := parseID(flags, args)
+
+	res, err := client.ChangePersonPosition(ctx, &desc.ChangePersonPositionRequest{Id: id, Position: *position})
+	if err != nil {
+		return err
+	}
+	return printResult(res, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\tPOSITION\n%d\t%s\n", id, res.GetPosition())
+	})
+}
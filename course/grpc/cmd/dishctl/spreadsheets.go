@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dishsheet"
+	"io"
+	"os"
+)
+
+// errRowsFailed makes dishctl exit with an error after the report has been printed.
+var errRowsFailed = errors.New("some rows have errors, nothing was imported")
+
+// spreadsheetFormat returns the -format flag or the format of the file name, "-" stands for stdin or stdout.
+func spreadsheetFormat(format, name string) (string, error) {
+	if format != "" {
+		if _, ok := dishsheet.ContentTypes[format]; !ok {
+			return "", fmt.Errorf("unsupported format %q, expected csv or xlsx", format)
+		}
+		return format, nil
+	}
+	if name == "-" {
+		return dishsheet.FormatCSV, nil
+	}
+	return dishsheet.FormatFromName(name)
+}
+
+func runImport(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "csv or xlsx, defaults to the file extension")
+	dryRun := flags.Bool("dry-run", false, "check the rows and report errors without creating dishes")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: dishctl import [-dry-run] [-format csv|xlsx] FILE\n\nFILE - reads stdin.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	name := flags.Arg(0)
+
+	sheetFormat, err := spreadsheetFormat(*format, name)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return err
+	}
+	rows, rowErrors, err := dishsheet.Read(sheetFormat, data)
+	if err != nil {
+		return err
+	}
+
+	report, err := dishsheet.Import(ctx, client, rows, rowErrors, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, rowError := range report.Errors {
+		fmt.Println(rowError)
+	}
+	switch {
+	case len(report.Errors) > 0:
+		fmt.Printf("checked %d rows, %d errors\n", report.Rows, len(report.Errors))
+		return errRowsFailed
+	case *dryRun:
+		fmt.Printf("checked %d rows, no errors\n", report.Rows)
+	default:
+		fmt.Printf("created %d dishes: %v\n", len(report.Created), report.Created)
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, client desc.DishV1Client, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "csv or xlsx, defaults to the extension of -o or csv")
+	output := flags.String("o", "-", "output file, - writes to stdout")
+	flags.Parse(args)
+
+	sheetFormat, err := spreadsheetFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.List(ctx, &desc.ListRequest{})
+	if err != nil {
+		return err
+	}
+	dishes := make([]*desc.DishInfo, 0, len(res.GetDishes()))
+	for _, dish := range res.GetDishes() {
+		dishes = append(dishes, dish.GetInfo())
+	}
+	data, err := dishsheet.Write(sheetFormat, dishes)
+	if err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// LogInPerson issues a token signed with AUTH_SECRET, callers send it as "authorization: Bearer <token>".
+// The gateway forwards the Authorization header of the HTTP request under this metadata key.
+const (
+	authSecretEnv         = "AUTH_SECRET"
+	authTokenTTLEnv       = "AUTH_TOKEN_TTL"
+	defaultAuthTokenTTL   = 12 * time.Hour
+	authorizationMetadata = "authorization"
+	bearerPrefix          = "Bearer "
+)
+
+var managerPositions = map[string]bool{"manager": true, "admin": true}
+
+// selfServicePosition is the only position callers other than managers can create persons with.
+const selfServicePosition = "user"
+
+// authSecret is loaded by main, the migrate command does not need it.
+var (
+	authSecret   []byte
+	authTokenTTL = durationFromEnv(authTokenTTLEnv, defaultAuthTokenTTL)
+)
+
+// loadAuthSecret reads AUTH_SECRET. Without it tokens are signed with a random secret
+// and stop being valid when the server restarts.
+func loadAuthSecret() []byte {
+	if secret := os.Getenv(authSecretEnv); secret != "" {
+		return []byte(secret)
+	}
+	log.Printf("%s is not set, tokens are signed with a random secret", authSecretEnv)
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// tokenClaims is the signed payload of a token.
+type tokenClaims struct {
+	PersonId  int32 `json:"sub"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// issueToken returns a token of the person valid until the returned time.
+// A token is the base64url encoded JSON claims and their HMAC-SHA256, separated by a dot.
+func issueToken(personId int32, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(authTokenTTL)
+	payload, err := json.Marshal(tokenClaims{PersonId: personId, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(tokenSignature(encoded)), expiresAt, nil
+}
+
+func tokenSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, authSecret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// verifyToken checks the signature and the expiry of token and returns the id of its person.
+func verifyToken(token string, now time.Time) (int32, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, errors.New("malformed token")
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, tokenSignature(payload)) {
+		return 0, errors.New("invalid token signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, errors.New("malformed token")
+	}
+	var claims tokenClaims
+	if err = json.Unmarshal(data, &claims); err != nil || claims.PersonId <= 0 {
+		return 0, errors.New("malformed token")
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return 0, errors.New("token has expired")
+	}
+	return claims.PersonId, nil
+}
+
+type actorKey struct{}
+
+// authInterceptor verifies the bearer token of the call and stores the id of its person for actorID.
+// Calls without a token are anonymous, calls with an invalid one are rejected.
+func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationMetadata)
+	if len(values) == 0 {
+		return handler(ctx, req)
+	}
+	token, ok := strings.CutPrefix(values[0], bearerPrefix)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	id, err := verifyToken(token, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return handler(context.WithValue(ctx, actorKey{}, id), req)
+}
+
+// actorID returns the id of the person making the call, ok is false for anonymous calls.
+func actorID(ctx context.Context) (int32, bool) {
+	id, ok := ctx.Value(actorKey{}).(int32)
+	return id, ok
+}
+
+// requireManager returns PermissionDenied unless the caller is a manager or an admin.
+func requireManager(ctx context.Context, q querier) error {
+	manager, err := isManager(ctx, q)
+	if err != nil {
+		return err
+	}
+	if !manager {
+		return status.Error(codes.PermissionDenied, "only managers can do this")
+	}
+	return nil
+}
+
+// checkNewPersonPosition returns PermissionDenied when a caller other than a manager
+// creates a person with a position other than selfServicePosition.
+func checkNewPersonPosition(ctx context.Context, q querier, position string) error {
+	if position == selfServicePosition {
+		return nil
+	}
+	manager, err := isManager(ctx, q)
+	if err != nil {
+		return err
+	}
+	if !manager {
+		return status.Errorf(codes.PermissionDenied, "only managers can create persons with position %q", position)
+	}
+	return nil
+}
+
+// isManager reports whether the caller is a manager or an admin, anonymous callers are not.
+func isManager(ctx context.Context, q querier) (bool, error) {
+	id, ok := actorID(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	query, args, err := squirrel.Select("position").
+		From("persons").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return false, errors.New("failed to build query")
+	}
+
+	var position string
+	err = q.QueryRow(ctx, query, args...).Scan(&position)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("failed to select person: %v", err)
+		return false, errors.New("failed to select person")
+	}
+	return managerPositions[position], nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	authSecret = []byte("secret")
+	now := time.Now()
+	token, expiresAt, err := issueToken(42, now)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(authTokenTTL)) {
+		t.Errorf("expires at %v, want %v", expiresAt, now.Add(authTokenTTL))
+	}
+	id, err := verifyToken(token, now)
+	if err != nil || id != 42 {
+		t.Fatalf("verifyToken = %d, %v, want 42", id, err)
+	}
+
+	if _, err = verifyToken(token, expiresAt); err == nil {
+		t.Error("expired token is accepted")
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	forged, _, _ := issueToken(1, now)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	if _, err = verifyToken(forgedPayload+"."+signature, now); err == nil {
+		t.Error("token with a swapped payload is accepted")
+	}
+	authSecret = []byte("other secret")
+	if _, err = verifyToken(payload+"."+signature, now); err == nil {
+		t.Error("token signed with another secret is accepted")
+	}
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	authSecret = []byte("secret")
+	token, _, err := issueToken(7, time.Now())
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	call := func(md metadata.MD) (int32, bool, error) {
+		var id int32
+		var ok bool
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		_, err := authInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			id, ok = actorID(ctx)
+			return nil, nil
+		})
+		return id, ok, err
+	}
+
+	if id, ok, err := call(metadata.Pairs(authorizationMetadata, bearerPrefix+token)); err != nil || !ok || id != 7 {
+		t.Errorf("valid token: actor %d, %v, %v", id, ok, err)
+	}
+	if _, ok, err := call(metadata.MD{}); err != nil || ok {
+		t.Errorf("no token: actor %v, %v, want anonymous", ok, err)
+	}
+	for _, value := range []string{"7", bearerPrefix + "garbage", bearerPrefix + token + "x"} {
+		if _, _, err := call(metadata.Pairs(authorizationMetadata, value)); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("authorization %q: %v, want Unauthenticated", value, err)
+		}
+	}
+	if _, ok, err := call(metadata.Pairs("x-person-id", "1")); err != nil || ok {
+		t.Errorf("x-person-id header: actor %v, %v, want anonymous", ok, err)
+	}
+}
+
+// positionQuerier answers the position lookup of isManager from a map of person ids to positions.
+type positionQuerier map[int32]string
+
+func (q positionQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	panic("unexpected query: " + sql)
+}
+
+func (q positionQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return positionRow{q, args[0].(int32)}
+}
+
+type positionRow struct {
+	q  positionQuerier
+	id int32
+}
+
+func (r positionRow) Scan(dest ...interface{}) error {
+	position, ok := r.q[r.id]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	*dest[0].(*string) = position
+	return nil
+}
+
+func TestManagerChecks(t *testing.T) {
+	q := positionQuerier{1: "user", 2: "manager", 3: "admin", 4: "cook"}
+	actor := func(id int32) context.Context {
+		return context.WithValue(context.Background(), actorKey{}, id)
+	}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		position string
+		create   codes.Code
+		change   codes.Code
+	}{
+		{"anonymous user", context.Background(), "user", codes.OK, codes.PermissionDenied},
+		{"anonymous manager", context.Background(), "manager", codes.PermissionDenied, codes.PermissionDenied},
+		{"anonymous admin", context.Background(), "admin", codes.PermissionDenied, codes.PermissionDenied},
+		{"user creates cook", actor(1), "cook", codes.PermissionDenied, codes.PermissionDenied},
+		{"cook creates manager", actor(4), "manager", codes.PermissionDenied, codes.PermissionDenied},
+		{"unknown person", actor(9), "manager", codes.PermissionDenied, codes.PermissionDenied},
+		{"manager creates manager", actor(2), "manager", codes.OK, codes.OK},
+		{"admin creates cook", actor(3), "cook", codes.OK, codes.OK},
+	}
+	for _, tt := range tests {
+		if err := checkNewPersonPosition(tt.ctx, q, tt.position); status.Code(err) != tt.create {
+			t.Errorf("%s: checkNewPersonPosition = %v, want %v", tt.name, err, tt.create)
+		}
+		if err := requireManager(tt.ctx, q); status.Code(err) != tt.change {
+			t.Errorf("%s: requireManager = %v, want %v", tt.name, err, tt.change)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"log"
+	"reflect"
+	"time"
+)
+
+// The gateway forwards the X-Request-Id header under this metadata key,
+// calls without it get a generated id which is returned in the response header.
+const requestIdMetadata = "x-request-id"
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditEntities maps the audited tables to the entity names stored in the log.
+var auditEntities = map[string]string{
+	"dishes":  "dish",
+	"persons": "person",
+}
+
+// auditRedacted columns are never written to the log.
+var auditRedacted = map[string]bool{"password": true}
+
+// execer is implemented by both *pgxpool.Pool and pgx.Tx.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+type requestIdKey struct{}
+
+func requestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(requestIdMetadata)) > 0 {
+		id = md.Get(requestIdMetadata)[0]
+	} else {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIdMetadata, id))
+	return handler(context.WithValue(ctx, requestIdKey{}, id), req)
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// rowSnapshot returns the row of table with the given id as a JSON object and locks it,
+// nil means there is no such row.
+func rowSnapshot(ctx context.Context, q querier, table string, id int32) (map[string]interface{}, error) {
+	query, args, err := squirrel.Select("to_jsonb(t)").
+		From(table + " t").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"t.id": id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	var row map[string]interface{}
+	err = q.QueryRow(ctx, query, args...).Scan(&row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		log.Printf("failed to select from %s: %v", table, err)
+		return nil, errors.New("failed to select from " + table)
+	}
+	for column := range auditRedacted {
+		delete(row, column)
+	}
+	return row, nil
+}
+
+// auditDiff keeps only the columns which differ between the snapshots.
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if before == nil || after == nil {
+		return before, after
+	}
+	changedBefore := make(map[string]interface{})
+	changedAfter := make(map[string]interface{})
+	for column, value := range after {
+		if old, ok := before[column]; !ok || !reflect.DeepEqual(old, value) {
+			changedBefore[column] = before[column]
+			changedAfter[column] = value
+		}
+	}
+	for column, old := range before {
+		if _, ok := after[column]; !ok {
+			changedBefore[column] = old
+			changedAfter[column] = nil
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+func jsonOrNil(value map[string]interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// writeAudit records a mutation of the row of table, it must be called in the transaction of the mutation.
+// before is nil for created rows and after is nil for removed ones.
+func writeAudit(ctx context.Context, e execer, table string, id int32, action string, before, after map[string]interface{}) error {
+	before, after = auditDiff(before, after)
+	beforeJSON, err := jsonOrNil(before)
+	if err != nil {
+		log.Printf("failed to encode audit record: %v", err)
+		return errors.New("failed to encode audit record")
+	}
+	afterJSON, err := jsonOrNil(after)
+	if err != nil {
+		log.Printf("failed to encode audit record: %v", err)
+		return errors.New("failed to encode audit record")
+	}
+
+	// The actor is the person of the verified token, anonymous calls are recorded without one.
+	var actor interface{}
+	actorId, authenticated := actorID(ctx)
+	if authenticated {
+		actor = actorId
+	}
+
+	query, args, err := squirrel.Insert("audit_events").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("actor_id", "authenticated", "occurred_at", "entity", "entity_id", "action", "before", "after", "request_id").
+		Values(actor, authenticated, sqlNow, auditEntities[table], id, action, beforeJSON, afterJSON, requestID(ctx)).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+
+	if _, err = e.Exec(ctx, query, args...); err != nil {
+		log.Printf("failed to insert audit record: %v", err)
+		return errors.New("failed to insert audit record")
+	}
+	return nil
+}
+
+func (s *server) ListAuditEvents(ctx context.Context, req *desc.ListAuditEventsRequest) (*desc.ListAuditEventsResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	limit := req.GetLimit()
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+
+	builderSelect := squirrel.Select("id", "actor_id", "authenticated", "occurred_at", "entity", "entity_id", "action", "before", "after", "request_id").
+		From("audit_events").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("occurred_at DESC", "id DESC").
+		Limit(uint64(limit))
+	if req.GetEntity() != "" {
+		builderSelect = builderSelect.Where(squirrel.Eq{"entity": req.GetEntity()})
+	}
+	if req.GetEntityId() != nil {
+		builderSelect = builderSelect.Where(squirrel.Eq{"entity_id": req.GetEntityId().GetValue()})
+	}
+	if req.GetActorId() != nil {
+		builderSelect = builderSelect.Where(squirrel.Eq{"actor_id": req.GetActorId().GetValue()})
+	}
+	if req.GetAuthenticated() != nil {
+		builderSelect = builderSelect.Where(squirrel.Eq{"authenticated": req.GetAuthenticated().GetValue()})
+	}
+	if req.GetAction() != "" {
+		builderSelect = builderSelect.Where(squirrel.Eq{"action": req.GetAction()})
+	}
+	if req.GetSince() != nil {
+		builderSelect = builderSelect.Where(squirrel.GtOrEq{"occurred_at": req.GetSince().AsTime()})
+	}
+	if req.GetUntil() != nil {
+		builderSelect = builderSelect.Where(squirrel.Lt{"occurred_at": req.GetUntil().AsTime()})
+	}
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select audit events: %v", err)
+		return nil, errors.New("failed to select audit events")
+	}
+	defer rows.Close()
+
+	var events []*desc.AuditEvent
+	for rows.Next() {
+		var event desc.AuditEvent
+		var actor *int32
+		var occurredAt time.Time
+		var before, after map[string]interface{}
+		err = rows.Scan(&event.Id, &actor, &event.Authenticated, &occurredAt, &event.Entity, &event.EntityId, &event.Action, &before, &after, &event.RequestId)
+		if err != nil {
+			log.Printf("failed to scan audit event: %v", err)
+			return nil, errors.New("failed to scan audit event")
+		}
+		if actor != nil {
+			event.ActorId = wrapperspb.Int32(*actor)
+		}
+		event.OccurredAt = timestamppb.New(occurredAt)
+		if before != nil {
+			if event.Before, err = structpb.NewStruct(before); err != nil {
+				log.Printf("failed to convert audit event: %v", err)
+				return nil, errors.New("failed to convert audit event")
+			}
+		}
+		if after != nil {
+			if event.After, err = structpb.NewStruct(after); err != nil {
+				log.Printf("failed to convert audit event: %v", err)
+				return nil, errors.New("failed to convert audit event")
+			}
+		}
+		events = append(events, &event)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read audit events: %v", err)
+		return nil, errors.New("failed to read audit events")
+	}
+	return &desc.ListAuditEventsResponse{Events: events}, nil
+}
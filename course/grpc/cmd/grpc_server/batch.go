@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"strings"
+)
+
+// maxBatchSize bounds the items of a batch RPC, larger imports are split by the client.
+const maxBatchSize = 500
+
+func checkBatchSize(n int, field string) error {
+	if n > maxBatchSize {
+		return violationsError([]*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Description: fmt.Sprintf("must have at most %d items", maxBatchSize),
+		}})
+	}
+	return nil
+}
+
+// describeViolations joins violations into a single message for the result of a batch item.
+func describeViolations(violations []*errdetails.BadRequest_FieldViolation) string {
+	parts := make([]string, 0, len(violations))
+	for _, v := range violations {
+		parts = append(parts, v.GetField()+": "+v.GetDescription())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// batchItemError converts the error of a per-item batch item, errors without a gRPC status become UNKNOWN.
+func batchItemError(err error) *desc.BatchItemError {
+	st := status.Convert(err)
+	return &desc.BatchItemError{Code: int32(st.Code()), Message: st.Message()}
+}
+
+// batchError fails an all-or-nothing batch with the error of the item at index.
+func batchError(index int, err error) error {
+	st := status.Convert(err)
+	return status.Errorf(st.Code(), "item %d: %s", index, st.Message())
+}
+
+// runBatchItem runs f in a savepoint of tx, so a failing item is rolled back alone.
+func runBatchItem(ctx context.Context, tx pgx.Tx, f func(tx pgx.Tx) error) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to create savepoint: %v", err)
+		return errors.New("failed to create savepoint")
+	}
+	defer savepoint.Rollback(ctx)
+
+	if err = f(savepoint); err != nil {
+		return err
+	}
+	if err = savepoint.Commit(ctx); err != nil {
+		log.Printf("failed to release savepoint: %v", err)
+		return errors.New("failed to release savepoint")
+	}
+	return nil
+}
+
+func (s *server) BatchCreateDishes(ctx context.Context, req *desc.BatchCreateDishesRequest) (*desc.BatchCreateDishesResponse, error) {
+	if err := checkBatchSize(len(req.GetDishes()), "dishes"); err != nil {
+		return nil, err
+	}
+	perItem := req.GetMode() == desc.BatchMode_BATCH_MODE_PER_ITEM
+
+	// Items are validated here rather than by the interceptor, see batchItemFields.
+	invalid := make(map[int]error)
+	var violations []*errdetails.BadRequest_FieldViolation
+	for i, info := range req.GetDishes() {
+		itemViolations := collectViolations(info.ProtoReflect(), fmt.Sprintf("dishes[%d].", i), nil, nil)
+		if len(itemViolations) > 0 {
+			invalid[i] = status.Error(codes.InvalidArgument, describeViolations(itemViolations))
+			violations = append(violations, itemViolations...)
+		}
+	}
+	if !perItem && len(violations) > 0 {
+		return nil, violationsError(violations)
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]*desc.BatchItemResult, 0, len(req.GetDishes()))
+	for i, info := range req.GetDishes() {
+		var id int32
+		err, ok := invalid[i]
+		if !ok {
+			err = runBatchItem(ctx, tx, func(tx pgx.Tx) error {
+				var err error
+				id, err = insertDish(ctx, tx, info)
+				return err
+			})
+		}
+		switch {
+		case err == nil:
+			results = append(results, &desc.BatchItemResult{Id: id})
+		case perItem:
+			results = append(results, &desc.BatchItemResult{Error: batchItemError(err)})
+		default:
+			return nil, batchError(i, err)
+		}
+	}
+
+	if req.GetValidateOnly() {
+		for _, result := range results {
+			result.Id = 0
+		}
+		return &desc.BatchCreateDishesResponse{Results: results}, nil
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert dishes")
+	}
+	return &desc.BatchCreateDishesResponse{Results: results}, nil
+}
+
+func (s *server) BatchGetDishes(ctx context.Context, req *desc.BatchGetDishesRequest) (*desc.BatchGetDishesResponse, error) {
+	if err := checkBatchSize(len(req.GetIds()), "ids"); err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if req.GetShowDeleted() {
+		if err = requireManager(ctx, pool); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]int32, 0, len(req.GetIds()))
+	seen := make(map[int32]bool, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	builderSelect := squirrel.Select(dishColumns...).
+		From("dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": ids})
+	if !req.GetShowDeleted() {
+		builderSelect = builderSelect.Where(notDeleted)
+	}
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select dishes: %v", err)
+		return nil, errors.New("failed to select dishes")
+	}
+	found := make(map[int32]*desc.Dish, len(ids))
+	for rows.Next() {
+		n, err := scanDish(rows)
+		if err != nil {
+			rows.Close()
+			log.Printf("failed to scan dish: %v", err)
+			return nil, errors.New("failed to scan dish")
+		}
+		found[n.GetId()] = n
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read dishes: %v", err)
+		return nil, errors.New("failed to read dishes")
+	}
+
+	dishes := make([]*desc.Dish, 0, len(found))
+	var missing []int32
+	for _, id := range ids {
+		if n, ok := found[id]; ok {
+			dishes = append(dishes, n)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 && req.GetMode() != desc.BatchMode_BATCH_MODE_PER_ITEM {
+		return nil, status.Errorf(codes.NotFound, "there are no dishes with ids %v in system", missing)
+	}
+
+	promos, err := loadPromotions(ctx, pool, "")
+	if err != nil {
+		return nil, err
+	}
+	applyDishPromotions(dishes, promos)
+
+	groups, err := loadModifierGroups(ctx, pool, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range dishes {
+		n.ModifierGroups = groups[n.GetId()]
+	}
+
+	return &desc.BatchGetDishesResponse{Dishes: dishes, MissingIds: missing}, nil
+}
+
+func (s *server) BatchDeleteDishes(ctx context.Context, req *desc.BatchDeleteDishesRequest) (*desc.BatchDeleteDishesResponse, error) {
+	if err := checkBatchSize(len(req.GetIds()), "ids"); err != nil {
+		return nil, err
+	}
+	perItem := req.GetMode() == desc.BatchMode_BATCH_MODE_PER_ITEM
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]*desc.BatchItemResult, 0, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		err := runBatchItem(ctx, tx, func(tx pgx.Tx) error {
+			return softDeleteDish(ctx, tx, id)
+		})
+		switch {
+		case err == nil:
+			results = append(results, &desc.BatchItemResult{Id: id})
+		case perItem:
+			results = append(results, &desc.BatchItemResult{Error: batchItemError(err)})
+		default:
+			return nil, batchError(i, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to delete dishes")
+	}
+	return &desc.BatchDeleteDishesResponse{Results: results}, nil
+}
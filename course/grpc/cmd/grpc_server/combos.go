@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"time"
+)
+
+// checkComboSlots validates slots and checks that every referenced dish exists.
+func checkComboSlots(ctx context.Context, pool *pgxpool.Pool, slots []*desc.ComboSlot) error {
+	if len(slots) == 0 {
+		return errors.New("combo has no slots")
+	}
+	for _, slot := range slots {
+		if slot.GetName() == "" {
+			return errors.New("combo slot name is empty")
+		}
+		if len(slot.GetDishIds()) == 0 {
+			return errors.New("combo slot has no dishes")
+		}
+		for _, dishId := range slot.GetDishIds() {
+			if err := checkDishExists(ctx, pool, dishId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func insertComboSlots(ctx context.Context, tx pgx.Tx, comboId int32, slots []*desc.ComboSlot) error {
+	builderSlots := squirrel.Insert("combo_slots").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("combo_id", "position", "name")
+	builderDishes := squirrel.Insert("combo_slot_dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("combo_id", "position", "dish_id").
+		Suffix("ON CONFLICT DO NOTHING")
+	for i, slot := range slots {
+		builderSlots = builderSlots.Values(comboId, i, slot.GetName())
+		for _, dishId := range slot.GetDishIds() {
+			builderDishes = builderDishes.Values(comboId, i, dishId)
+		}
+	}
+
+	for _, builder := range []squirrel.InsertBuilder{builderSlots, builderDishes} {
+		query, args, err := builder.ToSql()
+		if err != nil {
+			log.Printf("failed to build query: %v", err)
+			return errors.New("failed to build query")
+		}
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			log.Printf("failed to insert combo slots: %v", err)
+			return errors.New("failed to insert combo slots")
+		}
+	}
+	return nil
+}
+
+// selectCombos runs builderSelect over combos table and attaches slots to every combo.
+func selectCombos(ctx context.Context, q querier, builderSelect squirrel.SelectBuilder) ([]*desc.Combo, error) {
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select combos: %v", err)
+		return nil, errors.New("failed to select combos")
+	}
+	defer rows.Close()
+
+	combos := make([]*desc.Combo, 0)
+	byId := make(map[int32]*desc.Combo)
+	for rows.Next() {
+		c := &desc.Combo{Info: &desc.ComboInfo{}}
+		var createdAt, updatedAt time.Time
+		err = rows.Scan(&c.Id, &c.Info.Name, &c.Info.Description, &c.Info.Price, &c.Info.Available, &createdAt, &updatedAt)
+		if err != nil {
+			log.Printf("failed to scan combo: %v", err)
+			return nil, errors.New("failed to scan combo")
+		}
+		c.CreatedAt = timestamppb.New(createdAt)
+		c.UpdatedAt = timestamppb.New(updatedAt)
+		combos = append(combos, c)
+		byId[c.Id] = c
+	}
+	rows.Close()
+	if len(combos) == 0 {
+		return combos, nil
+	}
+
+	ids := make([]int32, 0, len(combos))
+	for _, c := range combos {
+		ids = append(ids, c.GetId())
+	}
+	query, args, err = squirrel.Select("s.combo_id", "s.position", "s.name", "d.dish_id").
+		From("combo_slots s").
+		LeftJoin("combo_slot_dishes d ON d.combo_id = s.combo_id AND d.position = s.position").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"s.combo_id": ids}).
+		OrderBy("s.combo_id", "s.position", "d.dish_id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	slotRows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select combo slots: %v", err)
+		return nil, errors.New("failed to select combo slots")
+	}
+	defer slotRows.Close()
+	for slotRows.Next() {
+		var comboId, position int32
+		var name string
+		var dishId *int32
+		if err = slotRows.Scan(&comboId, &position, &name, &dishId); err != nil {
+			log.Printf("failed to scan combo slot: %v", err)
+			return nil, errors.New("failed to scan combo slot")
+		}
+		c := byId[comboId]
+		if int(position) >= len(c.Info.Slots) {
+			c.Info.Slots = append(c.Info.Slots, &desc.ComboSlot{Name: name})
+		}
+		if dishId != nil {
+			slot := c.Info.Slots[len(c.Info.Slots)-1]
+			slot.DishIds = append(slot.DishIds, *dishId)
+		}
+	}
+	return combos, nil
+}
+
+func (s *server) CreateCombo(ctx context.Context, req *desc.CreateComboRequest) (*desc.CreateComboResponse, error) {
+	info := req.GetInfo()
+	if info.GetName() == "" {
+		return nil, errors.New("combo name is empty")
+	}
+	if info.GetPrice() < 0 {
+		return nil, errors.New("combo price is negative")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkComboSlots(ctx, pool, info.GetSlots()); err != nil {
+		return nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Insert("combos").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("name", "description", "price", "available", "created_at", "updated_at").
+		Values(info.GetName(), info.GetDescription(), info.GetPrice(), info.GetAvailable(), sqlNow, sqlNow).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var id int32
+	if err = tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		log.Printf("failed to insert combo: %v", err)
+		return nil, errors.New("failed to insert combo")
+	}
+
+	if err = insertComboSlots(ctx, tx, id, info.GetSlots()); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert combo")
+	}
+	return &desc.CreateComboResponse{Id: id}, nil
+}
+
+func (s *server) GetCombo(ctx context.Context, req *desc.GetComboRequest) (*desc.GetComboResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	combos, err := selectCombos(ctx, pool, squirrel.Select("id", "name", "description", "price", "available", "created_at", "updated_at").
+		From("combos").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}))
+	if err != nil {
+		return nil, err
+	}
+	if len(combos) == 0 {
+		return nil, errors.New("there is no combo with such id in system")
+	}
+	return &desc.GetComboResponse{Combo: combos[0]}, nil
+}
+
+func (s *server) ListCombos(ctx context.Context, req *desc.ListCombosRequest) (*desc.ListCombosResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	builderSelect := squirrel.Select("id", "name", "description", "price", "available", "created_at", "updated_at").
+		From("combos").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("id")
+	if req.GetOnlyAvailable() {
+		builderSelect = builderSelect.Where(squirrel.Eq{"available": true})
+	}
+
+	combos, err := selectCombos(ctx, pool, builderSelect)
+	if err != nil {
+		return nil, err
+	}
+	return &desc.ListCombosResponse{Combos: combos}, nil
+}
+
+func (s *server) UpdateCombo(ctx context.Context, req *desc.UpdateComboRequest) (*emptypb.Empty, error) {
+	info := req.GetInfo()
+	if info.GetName() != nil && info.GetName().GetValue() == "" {
+		return nil, errors.New("combo name is empty")
+	}
+	if info.GetPrice() != nil && info.GetPrice().GetValue() < 0 {
+		return nil, errors.New("combo price is negative")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkExists(ctx, pool, "combos", req.GetId()); err != nil {
+		return nil, err
+	}
+	if info.GetSlots() != nil {
+		if err = checkComboSlots(ctx, pool, info.GetSlots().GetSlots()); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	builderUpdate := squirrel.Update("combos").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("updated_at", sqlNow).
+		Where(squirrel.Eq{"id": req.GetId()})
+	if info.GetName() != nil {
+		builderUpdate = builderUpdate.Set("name", info.GetName().GetValue())
+	}
+	if info.GetDescription() != nil {
+		builderUpdate = builderUpdate.Set("description", info.GetDescription().GetValue())
+	}
+	if info.GetPrice() != nil {
+		builderUpdate = builderUpdate.Set("price", info.GetPrice().GetValue())
+	}
+	if info.GetAvailable() != nil {
+		builderUpdate = builderUpdate.Set("available", info.GetAvailable().GetValue())
+	}
+
+	query, args, err := builderUpdate.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		log.Printf("failed to update combo: %v", err)
+		return nil, errors.New("failed to update combo")
+	}
+
+	if info.GetSlots() != nil {
+		query, args, err = squirrel.Delete("combo_slots").
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{"combo_id": req.GetId()}).
+			ToSql()
+		if err != nil {
+			log.Printf("failed to build query: %v", err)
+			return nil, errors.New("failed to build query")
+		}
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			log.Printf("failed to delete combo slots: %v", err)
+			return nil, errors.New("failed to delete combo slots")
+		}
+		if err = insertComboSlots(ctx, tx, req.GetId(), info.GetSlots().GetSlots()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to update combo")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) DeleteCombo(ctx context.Context, req *desc.DeleteComboRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := squirrel.Delete("combos").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete combo: %v", err)
+		return nil, errors.New("failed to delete combo")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no combo with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"github.com/jackc/pgconn"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+)
+
+// SQLSTATE codes of integrity constraint violations.
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+	pgExclusionViolation  = "23P01"
+)
+
+// constraintFields maps database constraints to the request fields they check.
+var constraintFields = map[string]string{
+	"dishes_author_fkey": "author",
+	"persons_pkey":       "id",
+	"persons_login_key":  "login",
+}
+
+// constraintDescriptions are the client facing messages of the violated constraints.
+var constraintDescriptions = map[string]string{
+	"dishes_author_fkey": "there is no person with such id in system",
+	"persons_pkey":       "there is a person with such id in system",
+	"persons_login_key":  "there is a person with such login in system",
+	// Concurrent reservations pass the overlap check of CreateReservation, the constraint stops the later one.
+	"reservations_no_overlap": "table is already reserved for this time",
+}
+
+// constraintError maps an integrity constraint violation to InvalidArgument, AlreadyExists for
+// unique violations or FailedPrecondition for exclusion violations. Other errors are logged and replaced by message, like everywhere else.
+func constraintError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		log.Printf("%s: %v", message, err)
+		return errors.New(message)
+	}
+
+	code := codes.InvalidArgument
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		code = codes.AlreadyExists
+	case pgExclusionViolation:
+		code = codes.FailedPrecondition
+	case pgNotNullViolation, pgForeignKeyViolation, pgCheckViolation:
+	default:
+		log.Printf("%s: %v", message, err)
+		return errors.New(message)
+	}
+
+	description, ok := constraintDescriptions[pgErr.ConstraintName]
+	if !ok {
+		description = pgErr.Message
+	}
+	field, ok := constraintFields[pgErr.ConstraintName]
+	if !ok {
+		field = pgErr.ColumnName
+	}
+	if code != codes.InvalidArgument || field == "" {
+		return status.Error(code, description)
+	}
+	st, detailsErr := status.New(code, description).WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	})
+	if detailsErr != nil {
+		return status.Error(code, description)
+	}
+	return st.Err()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"github.com/jackc/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestConstraintError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"overlapping reservation", &pgconn.PgError{Code: pgExclusionViolation, ConstraintName: "reservations_no_overlap"},
+			codes.FailedPrecondition, "table is already reserved for this time"},
+		{"duplicate login", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "persons_login_key"},
+			codes.AlreadyExists, "there is a person with such login in system"},
+		{"unknown author", &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: "dishes_author_fkey"},
+			codes.InvalidArgument, "there is no person with such id in system"},
+		{"other database error", &pgconn.PgError{Code: "40001"}, codes.Unknown, "failed to insert"},
+		{"connection error", errors.New("connection reset"), codes.Unknown, "failed to insert"},
+	}
+	for _, tt := range tests {
+		st := status.Convert(constraintError(tt.err, "failed to insert"))
+		if st.Code() != tt.code || st.Message() != tt.message {
+			t.Errorf("%s: %v %q, want %v %q", tt.name, st.Code(), st.Message(), tt.code, tt.message)
+		}
+	}
+}
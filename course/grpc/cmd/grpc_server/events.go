@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Domain events written to the outbox, other systems learn about changes from them.
+const (
+	eventDishCreated           = "DishCreated"
+	eventDishUpdated           = "DishUpdated"
+	eventDishPriceChanged      = "DishPriceChanged"
+	eventDishDeleted           = "DishDeleted"
+	eventDishRestored          = "DishRestored"
+	eventPersonCreated         = "PersonCreated"
+	eventPersonPositionChanged = "PersonPositionChanged"
+	eventOrderCreated          = "OrderCreated"
+)
+
+var eventTypes = []string{
+	eventDishCreated,
+	eventDishUpdated,
+	eventDishPriceChanged,
+	eventDishDeleted,
+	eventDishRestored,
+	eventPersonCreated,
+	eventPersonPositionChanged,
+	eventOrderCreated,
+}
+
+// domainEvent is a domain event as stored in the outbox and delivered to the sinks.
+type domainEvent struct {
+	Id          int64                  `json:"id"`
+	Type        string                 `json:"type"`
+	Aggregate   string                 `json:"aggregate"`
+	AggregateId int32                  `json:"aggregate_id"`
+	OccurredAt  time.Time              `json:"occurred_at"`
+	Payload     map[string]interface{} `json:"payload"`
+	// attempts counts the failed publications of an event claimed by the relay.
+	attempts int32
+}
+
+// recordMutation writes the audit record and the domain events of a mutation,
+// it must be called in the transaction of the mutation.
+func recordMutation(ctx context.Context, e execer, table string, id int32, action string, before, after map[string]interface{}) error {
+	if err := writeAudit(ctx, e, table, id, action, before, after); err != nil {
+		return err
+	}
+	for _, event := range domainEvents(table, id, action, before, after) {
+		if err := writeEvent(ctx, e, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// domainEvents derives the events of a mutation from the row snapshots taken around it.
+func domainEvents(table string, id int32, action string, before, after map[string]interface{}) []domainEvent {
+	aggregate := auditEntities[table]
+	event := func(eventType string, payload map[string]interface{}) domainEvent {
+		return domainEvent{Type: eventType, Aggregate: aggregate, AggregateId: id, Payload: payload}
+	}
+
+	var events []domainEvent
+	switch table {
+	case "dishes":
+		switch action {
+		case "create":
+			events = append(events, event(eventDishCreated, map[string]interface{}{"dish": after}))
+		case "delete":
+			events = append(events, event(eventDishDeleted, map[string]interface{}{"dish_id": id}))
+		case "restore":
+			events = append(events, event(eventDishRestored, map[string]interface{}{"dish": after}))
+		case "update":
+			events = append(events, event(eventDishUpdated, map[string]interface{}{"dish": after, "changed": changedColumns(before, after)}))
+			if !reflect.DeepEqual(before["price"], after["price"]) {
+				events = append(events, event(eventDishPriceChanged, map[string]interface{}{
+					"dish_id":   id,
+					"old_price": before["price"],
+					"new_price": after["price"],
+				}))
+			}
+		}
+	case "persons":
+		switch action {
+		case "create":
+			events = append(events, event(eventPersonCreated, map[string]interface{}{"person": after}))
+		case "change_position":
+			if !reflect.DeepEqual(before["position"], after["position"]) {
+				events = append(events, event(eventPersonPositionChanged, map[string]interface{}{
+					"person_id":    id,
+					"old_position": before["position"],
+					"new_position": after["position"],
+				}))
+			}
+		}
+	}
+	return events
+}
+
+// changedColumns lists the columns of a row which differ between the snapshots, version and timestamps excluded.
+func changedColumns(before, after map[string]interface{}) []string {
+	changed := []string{}
+	changedBefore, _ := auditDiff(before, after)
+	for column := range changedBefore {
+		if column == "version" || column == "updated_at" {
+			continue
+		}
+		changed = append(changed, column)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// orderCreatedEvent carries the whole order in the JSON form of the API.
+func orderCreatedEvent(order *desc.Order) (domainEvent, error) {
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(order)
+	if err != nil {
+		return domainEvent{}, err
+	}
+	var payload map[string]interface{}
+	if err = json.Unmarshal(b, &payload); err != nil {
+		return domainEvent{}, err
+	}
+	return domainEvent{Type: eventOrderCreated, Aggregate: "order", AggregateId: order.GetId(), Payload: map[string]interface{}{"order": payload}}, nil
+}
+
+func writeEvent(ctx context.Context, e execer, event domainEvent) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		log.Printf("failed to encode event: %v", err)
+		return errors.New("failed to encode event")
+	}
+
+	query, args, err := squirrel.Insert("outbox").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("event_type", "aggregate", "aggregate_id", "payload", "occurred_at").
+		Values(event.Type, event.Aggregate, event.AggregateId, string(payload), sqlNow).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+
+	if _, err = e.Exec(ctx, query, args...); err != nil {
+		log.Printf("failed to insert event: %v", err)
+		return errors.New("failed to insert event")
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"log"
+	"time"
+)
+
+// checkExists returns an error when there is no row with given id in table.
+func checkExists(ctx context.Context, q querier, table string, id int32) error {
+	builderSelect := squirrel.Select("id").
+		From(table).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": id})
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+
+	err = q.QueryRow(ctx, query, args...).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("there is no row with id %d in %s", id, table)
+		return errors.New("there is no " + table + " with such id in system")
+	} else if err != nil {
+		log.Printf("failed to select from %s: %v", table, err)
+		return errors.New("failed to select from " + table)
+	}
+	return nil
+}
+
+// favouritesOwner returns the person whose favourites and collections a call manages, which is
+// always the caller. personId of the request may be left 0 or must name the caller.
+func favouritesOwner(ctx context.Context, personId int32) (int32, error) {
+	actor, ok := actorID(ctx)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "log in to manage favourites and collections")
+	}
+	if personId != 0 && personId != actor {
+		return 0, status.Error(codes.PermissionDenied, "favourites and collections of other persons are private")
+	}
+	return actor, nil
+}
+
+// checkCollectionOwner returns an error when the collection does not exist or belongs to another person.
+func checkCollectionOwner(ctx context.Context, pool *pgxpool.Pool, personId, collectionId int32) error {
+	builderSelect := squirrel.Select("person_id").
+		From("collections").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": collectionId})
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+
+	var owner int32
+	err = pool.QueryRow(ctx, query, args...).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("there is no collection with such id in system: %v", err)
+		return errors.New("there is no collection with such id in system")
+	} else if err != nil {
+		log.Printf("failed to select collection: %v", err)
+		return errors.New("failed to select collection")
+	}
+	if owner != personId {
+		return errors.New("collection belongs to another person")
+	}
+	return nil
+}
+
+func (s *server) AddFavourite(ctx context.Context, req *desc.AddFavouriteRequest) (*emptypb.Empty, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkExists(ctx, pool, "persons", personId); err != nil {
+		return nil, err
+	}
+	if err = checkDishExists(ctx, pool, req.GetDishId()); err != nil {
+		return nil, err
+	}
+
+	builderInsert := squirrel.Insert("favourites").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("person_id", "dish_id").
+		Values(personId, req.GetDishId()).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, args, err := builderInsert.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	_, err = pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to insert favourite: %v", err)
+		return nil, errors.New("failed to insert favourite")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) RemoveFavourite(ctx context.Context, req *desc.RemoveFavouriteRequest) (*emptypb.Empty, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	builderDelete := squirrel.Delete("favourites").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"person_id": personId, "dish_id": req.GetDishId()})
+
+	query, args, err := builderDelete.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete favourite: %v", err)
+		return nil, errors.New("failed to delete favourite")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("dish is not in favourites of this person")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListFavourites(ctx context.Context, req *desc.ListFavouritesRequest) (*desc.ListFavouritesResponse, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkExists(ctx, pool, "persons", personId); err != nil {
+		return nil, err
+	}
+
+	res, err := s.List(ctx, &desc.ListRequest{FavouriteOf: wrapperspb.Int32(personId)})
+	if err != nil {
+		return nil, err
+	}
+	return &desc.ListFavouritesResponse{Dishes: res.GetDishes()}, nil
+}
+
+func (s *server) CreateCollection(ctx context.Context, req *desc.CreateCollectionRequest) (*desc.CreateCollectionResponse, error) {
+	if req.GetName() == "" {
+		return nil, errors.New("collection name is empty")
+	}
+
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkExists(ctx, pool, "persons", personId); err != nil {
+		return nil, err
+	}
+
+	builderInsert := squirrel.Insert("collections").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("person_id", "name", "created_at").
+		Values(personId, req.GetName(), sqlNow).
+		Suffix("RETURNING id")
+
+	query, args, err := builderInsert.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	var id int32
+	err = pool.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		log.Printf("failed to insert collection: %v", err)
+		return nil, errors.New("failed to insert collection")
+	}
+	return &desc.CreateCollectionResponse{Id: id}, nil
+}
+
+func (s *server) DeleteCollection(ctx context.Context, req *desc.DeleteCollectionRequest) (*emptypb.Empty, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkCollectionOwner(ctx, pool, personId, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	builderDelete := squirrel.Delete("collections").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()})
+
+	query, args, err := builderDelete.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	_, err = pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete collection: %v", err)
+		return nil, errors.New("failed to delete collection")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListCollections(ctx context.Context, req *desc.ListCollectionsRequest) (*desc.ListCollectionsResponse, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	builderSelect := squirrel.Select("c.id", "c.name", "c.created_at", "cd.dish_id").
+		From("collections c").
+		LeftJoin("collection_dishes cd ON cd.collection_id = c.id").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"c.person_id": personId}).
+		OrderBy("c.created_at", "c.id", "cd.dish_id")
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select collections: %v", err)
+		return nil, errors.New("failed to select collections")
+	}
+	defer rows.Close()
+
+	collections := make([]*desc.Collection, 0)
+	var last *desc.Collection
+	for rows.Next() {
+		var id int32
+		var name string
+		var createdAt time.Time
+		var dishId *int32
+		err = rows.Scan(&id, &name, &createdAt, &dishId)
+		if err != nil {
+			log.Printf("failed to scan collection: %v", err)
+			return nil, errors.New("failed to scan collection")
+		}
+		if last == nil || last.GetId() != id {
+			last = &desc.Collection{
+				Id:        id,
+				PersonId:  personId,
+				Name:      name,
+				DishIds:   make([]int32, 0),
+				CreatedAt: timestamppb.New(createdAt),
+			}
+			collections = append(collections, last)
+		}
+		if dishId != nil {
+			last.DishIds = append(last.DishIds, *dishId)
+		}
+	}
+	return &desc.ListCollectionsResponse{Collections: collections}, nil
+}
+
+func (s *server) AddDishToCollection(ctx context.Context, req *desc.AddDishToCollectionRequest) (*emptypb.Empty, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkCollectionOwner(ctx, pool, personId, req.GetCollectionId()); err != nil {
+		return nil, err
+	}
+	if err = checkDishExists(ctx, pool, req.GetDishId()); err != nil {
+		return nil, err
+	}
+
+	builderInsert := squirrel.Insert("collection_dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("collection_id", "dish_id").
+		Values(req.GetCollectionId(), req.GetDishId()).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, args, err := builderInsert.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	_, err = pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to add dish to collection: %v", err)
+		return nil, errors.New("failed to add dish to collection")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) RemoveDishFromCollection(ctx context.Context, req *desc.RemoveDishFromCollectionRequest) (*emptypb.Empty, error) {
+	personId, err := favouritesOwner(ctx, req.GetPersonId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkCollectionOwner(ctx, pool, personId, req.GetCollectionId()); err != nil {
+		return nil, err
+	}
+
+	builderDelete := squirrel.Delete("collection_dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"collection_id": req.GetCollectionId(), "dish_id": req.GetDishId()})
+
+	query, args, err := builderDelete.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to remove dish from collection: %v", err)
+		return nil, errors.New("failed to remove dish from collection")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("dish is not in this collection")
+	}
+	return &emptypb.Empty{}, nil
+}
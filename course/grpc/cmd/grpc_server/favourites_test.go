@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+)
+
+func TestFavouritesOwner(t *testing.T) {
+	actor := context.WithValue(context.Background(), actorKey{}, int32(5))
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		personId int32
+		want     int32
+		code     codes.Code
+	}{
+		{"anonymous", context.Background(), 0, 0, codes.Unauthenticated},
+		{"anonymous with person", context.Background(), 5, 0, codes.Unauthenticated},
+		{"caller by default", actor, 0, 5, codes.OK},
+		{"caller named", actor, 5, 5, codes.OK},
+		{"other person", actor, 6, 0, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		got, err := favouritesOwner(tt.ctx, tt.personId)
+		if status.Code(err) != tt.code || got != tt.want {
+			t.Errorf("%s: favouritesOwner = %d, %v, want %d, %v", tt.name, got, err, tt.want, tt.code)
+		}
+	}
+}
+
+// The owner is checked before connecting to the database, so denied calls need no database.
+func TestFavouritesDenied(t *testing.T) {
+	s := &server{}
+	calls := map[string]func(ctx context.Context, personId int32) error{
+		"AddFavourite": func(ctx context.Context, personId int32) error {
+			_, err := s.AddFavourite(ctx, &desc.AddFavouriteRequest{PersonId: personId, DishId: 1})
+			return err
+		},
+		"RemoveFavourite": func(ctx context.Context, personId int32) error {
+			_, err := s.RemoveFavourite(ctx, &desc.RemoveFavouriteRequest{PersonId: personId, DishId: 1})
+			return err
+		},
+		"ListFavourites": func(ctx context.Context, personId int32) error {
+			_, err := s.ListFavourites(ctx, &desc.ListFavouritesRequest{PersonId: personId})
+			return err
+		},
+		"CreateCollection": func(ctx context.Context, personId int32) error {
+			_, err := s.CreateCollection(ctx, &desc.CreateCollectionRequest{PersonId: personId, Name: "Weekend"})
+			return err
+		},
+		"DeleteCollection": func(ctx context.Context, personId int32) error {
+			_, err := s.DeleteCollection(ctx, &desc.DeleteCollectionRequest{PersonId: personId, Id: 1})
+			return err
+		},
+		"ListCollections": func(ctx context.Context, personId int32) error {
+			_, err := s.ListCollections(ctx, &desc.ListCollectionsRequest{PersonId: personId})
+			return err
+		},
+		"AddDishToCollection": func(ctx context.Context, personId int32) error {
+			_, err := s.AddDishToCollection(ctx, &desc.AddDishToCollectionRequest{PersonId: personId, CollectionId: 1, DishId: 1})
+			return err
+		},
+		"RemoveDishFromCollection": func(ctx context.Context, personId int32) error {
+			_, err := s.RemoveDishFromCollection(ctx, &desc.RemoveDishFromCollectionRequest{PersonId: personId, CollectionId: 1, DishId: 1})
+			return err
+		},
+		"List": func(ctx context.Context, personId int32) error {
+			_, err := s.List(ctx, &desc.ListRequest{FavouriteOf: wrapperspb.Int32(personId)})
+			return err
+		},
+	}
+	actor := context.WithValue(context.Background(), actorKey{}, int32(5))
+	for name, call := range calls {
+		if err := call(context.Background(), 5); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s anonymously: %v, want Unauthenticated", name, err)
+		}
+		if err := call(actor, 6); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s for another person: %v, want PermissionDenied", name, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// dishUpdate returns the new dish values and the DishInfo fields to write.
+// Requests with the deprecated wrapper based info are converted to the same form.
+func dishUpdate(req *desc.UpdateRequest) (*desc.DishInfo, []string, error) {
+	if req.GetUpdateMask() != nil {
+		if req.GetInfo() != nil {
+			return nil, nil, status.Error(codes.InvalidArgument, "info can not be combined with update_mask")
+		}
+		mask := req.GetUpdateMask()
+		if !mask.IsValid(&desc.DishInfo{}) {
+			return nil, nil, status.Error(codes.InvalidArgument, "update_mask contains unknown dish fields")
+		}
+		mask.Normalize()
+		dish := req.GetDish()
+		if dish == nil {
+			dish = &desc.DishInfo{}
+		}
+		return dish, mask.GetPaths(), nil
+	}
+
+	dish := &desc.DishInfo{}
+	mask := &fieldmaskpb.FieldMask{}
+	target := dish.ProtoReflect()
+	req.GetInfo().ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		field := target.Descriptor().Fields().ByName(fd.Name())
+		if field == nil {
+			return true
+		}
+		value := v.Message().Get(v.Message().Descriptor().Fields().ByName("value"))
+		if field.Kind() == protoreflect.Int32Kind {
+			value = protoreflect.ValueOfInt32(int32(value.Int()))
+		}
+		target.Set(field, value)
+		mask.Paths = append(mask.Paths, string(fd.Name()))
+		return true
+	})
+	mask.Normalize()
+	return dish, mask.GetPaths(), nil
+}
+
+// setMaskedColumns adds a SET clause for every masked field, columns of dishes are named like DishInfo fields.
+// Repeated fields are string lists stored in array columns.
+func setMaskedColumns(builder squirrel.UpdateBuilder, msg protoreflect.ProtoMessage, paths []string) squirrel.UpdateBuilder {
+	m := msg.ProtoReflect()
+	for _, path := range paths {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(path))
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			items := make([]string, 0, list.Len())
+			for i := 0; i < list.Len(); i++ {
+				items = append(items, list.Get(i).String())
+			}
+			builder = builder.Set(path, items)
+			continue
+		}
+		if path == "author" && m.Get(fd).Int() == 0 {
+			// Author 0 is a dish without an author, as in insertDish.
+			builder = builder.Set(path, nil)
+			continue
+		}
+		builder = builder.Set(path, m.Get(fd).Interface())
+	}
+	return builder
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDishUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   *desc.UpdateRequest
+		paths []string
+		dish  *desc.DishInfo
+		code  codes.Code
+	}{
+		{
+			name:  "merge patch",
+			req:   &desc.UpdateRequest{Dish: &desc.DishInfo{Name: "Soup", Price: 300}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price", "name"}}},
+			paths: []string{"name", "price"},
+			dish:  &desc.DishInfo{Name: "Soup", Price: 300},
+		},
+		{
+			// A key set to null is in the mask with the zero value, which clears the column.
+			name:  "cleared field",
+			req:   &desc.UpdateRequest{Dish: &desc.DishInfo{Name: "Soup"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "description"}}},
+			paths: []string{"description", "name"},
+			dish:  &desc.DishInfo{Name: "Soup"},
+		},
+		{
+			name:  "empty body",
+			req:   &desc.UpdateRequest{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"category"}}},
+			paths: []string{"category"},
+			dish:  &desc.DishInfo{},
+		},
+		{
+			name: "unknown field",
+			req:  &desc.UpdateRequest{Dish: &desc.DishInfo{}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"colour"}}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "mask with info",
+			req:  &desc.UpdateRequest{Info: &desc.UpdateDishInfo{Name: wrapperspb.String("Soup")}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "deprecated info",
+			req:   &desc.UpdateRequest{Info: &desc.UpdateDishInfo{Name: wrapperspb.String("Soup"), Price: wrapperspb.Int64(0)}},
+			paths: []string{"name", "price"},
+			dish:  &desc.DishInfo{Name: "Soup"},
+		},
+	}
+	for _, tt := range tests {
+		dish, paths, err := dishUpdate(tt.req)
+		if status.Code(err) != tt.code {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.code)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("%s: paths %v, want %v", tt.name, paths, tt.paths)
+		}
+		if dish.GetName() != tt.dish.GetName() || dish.GetPrice() != tt.dish.GetPrice() || dish.GetDescription() != "" {
+			t.Errorf("%s: dish %v, want %v", tt.name, dish, tt.dish)
+		}
+	}
+}
+
+func TestSetMaskedColumns(t *testing.T) {
+	dish := &desc.DishInfo{Name: "Soup", Price: 300, Description: "Hot", Allergens: []string{"milk", "celery"}}
+	query, args, err := setMaskedColumns(squirrel.Update("dishes").PlaceholderFormat(squirrel.Dollar), dish, []string{"allergens", "name", "price"}).ToSql()
+	if err != nil {
+		t.Fatalf("setMaskedColumns: %v", err)
+	}
+	if want := "UPDATE dishes SET allergens = $1, name = $2, price = $3"; query != want {
+		t.Errorf("query %q, want %q", query, want)
+	}
+	if want := []interface{}{[]string{"milk", "celery"}, "Soup", int32(300)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args %v, want %v", args, want)
+	}
+	if strings.Contains(query, "description") {
+		t.Errorf("unmasked description is written: %q", query)
+	}
+
+	for _, author := range []int32{0, 5} {
+		query, args, err = setMaskedColumns(squirrel.Update("dishes").PlaceholderFormat(squirrel.Dollar), &desc.DishInfo{Author: author}, []string{"author"}).ToSql()
+		if err != nil {
+			t.Fatalf("author %d: %v", author, err)
+		}
+		var want interface{} = author
+		if author == 0 {
+			want = nil
+		}
+		if query != "UPDATE dishes SET author = $1" || len(args) != 1 || args[0] != want {
+			t.Errorf("author %d: %q %v, want %v", author, query, args, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// gRPC-Web lets the browser frontend call the server directly, without the Envoy proxy.
+// It is served on a separate HTTP/1.1 address and is disabled unless GRPC_WEB_ADDR is set.
+const (
+	grpcWebAddrEnv       = "GRPC_WEB_ADDR"
+	grpcWebOriginsEnv    = "GRPC_WEB_ALLOWED_ORIGINS"
+	defaultGRPCWebOrigin = "http://localhost:3000"
+)
+
+// allowedOrigins parses a comma separated list of origins, "*" allows any origin.
+func allowedOrigins(value string) func(origin string) bool {
+	origins := make(map[string]bool)
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin != "" {
+			origins[origin] = true
+		}
+	}
+	return func(origin string) bool {
+		return origins["*"] || origins[origin]
+	}
+}
+
+func serveGRPCWeb(s *grpc.Server) {
+	addr := os.Getenv(grpcWebAddrEnv)
+	if addr == "" {
+		return
+	}
+	origins := os.Getenv(grpcWebOriginsEnv)
+	if origins == "" {
+		origins = defaultGRPCWebOrigin
+	}
+
+	wrapped := grpcweb.WrapServer(s,
+		grpcweb.WithOriginFunc(allowedOrigins(origins)),
+		grpcweb.WithAllowedRequestHeaders([]string{"content-type", "x-grpc-web", "x-user-agent", authorizationMetadata, requestIdMetadata}),
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wrapped.IsGrpcWebRequest(r) || wrapped.IsAcceptableGrpcCorsRequest(r) {
+			wrapped.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	go func() {
+		log.Printf("grpc-web listening at %v, allowed origins: %v", addr, origins)
+		if err := http.ListenAndServe(addr, handler); err != nil {
+			log.Fatalf("failed to serve grpc-web: %v", err)
+		}
+	}()
+}
@@ -6,23 +6,45 @@ import (
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
-	"github.com/brianvoe/gofakeit"
+	reservationDesc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/reservation_v1"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"log"
 	"net"
+	"os"
 	"time"
 )
 
-const (
-	dbDSN    = "host=course-db-1 port=5432 dbname=note user=note-user password=note-password sslmode=disable"
-	grpcPort = 50051
+const grpcPort = 50051
+
+// dbDSN is built from the DB_* variables of docker-compose, the defaults match the compose database.
+// Sessions run in UTC, so timestamps rendered by the database (audit snapshots, event payloads) are UTC as well.
+var dbDSN = fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=disable timezone=UTC",
+	envOr("DB_HOST", "course-db-1"),
+	envOr("DB_PORT", "5432"),
+	envOr("DB_NAME", "note"),
+	envOr("DB_USER", "note-user"),
+	envOr("DB_PASSWORD", "note-password"),
 )
 
+// sqlNow lets the database stamp rows, timestamp columns are timestamptz and keep the instant
+// regardless of the timezone of the server.
+var sqlNow = squirrel.Expr("now()")
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
 /*
 	type SyncMap struct {
 		elems map[int64]*desc.Note
@@ -44,8 +66,45 @@ type server struct {
 	desc.UnimplementedDishV1Server
 }
 
+// dishColumns are the columns read by scanDish.
+var dishColumns = []string{"id", "name", "price", "description", "composition", "COALESCE(author, 0)", "photo_url", "category", "created_at", "updated_at", "version", "deleted_at", "allergens"}
+
+func scanDish(row pgx.Row) (*desc.Dish, error) {
+	var id, author, price, version int32
+	var description, composition, photo_url, name, category string
+	var createdAt time.Time
+	var updatedAt time.Time
+	var deletedAt *time.Time
+	var allergens []string
+
+	err := row.Scan(&id, &name, &price, &description, &composition, &author, &photo_url, &category, &createdAt, &updatedAt, &version, &deletedAt, &allergens)
+	if err != nil {
+		return nil, err
+	}
+	n := &desc.Dish{
+		Id: id,
+		Info: &desc.DishInfo{
+			Name:        name,
+			Price:       price,
+			Description: description,
+			Composition: composition,
+			Author:      author,
+			PhotoUrl:    photo_url,
+			Category:    category,
+			Allergens:   allergens,
+		},
+		CreatedAt: timestamppb.New(createdAt),
+		UpdatedAt: timestamppb.New(updatedAt),
+		Version:   version,
+	}
+	if deletedAt != nil {
+		n.DeletedAt = timestamppb.New(*deletedAt)
+	}
+	return n, nil
+}
+
 func (s *server) Get(ctx context.Context, req *desc.GetRequest) (*desc.GetResponse, error) {
-	log.Printf("Note id: %d", req.GetId())
+	log.Printf("Dish id: %d", req.GetId())
 
 	pool, err := pgxpool.Connect(ctx, dbDSN)
 	if err != nil {
@@ -55,11 +114,20 @@ func (s *server) Get(ctx context.Context, req *desc.GetRequest) (*desc.GetRespon
 	}
 	defer pool.Close()
 
-	builderSelectOne := squirrel.Select("id", "name", "price", "description", "composition", "author", "photo_url", "created_at", "updated_at").
-		From("note").
+	if req.GetShowDeleted() {
+		if err = requireManager(ctx, pool); err != nil {
+			return nil, err
+		}
+	}
+
+	builderSelectOne := squirrel.Select(dishColumns...).
+		From("dishes").
 		PlaceholderFormat(squirrel.Dollar).
 		Where(squirrel.Eq{"id": req.GetId()}).
 		Limit(1)
+	if !req.GetShowDeleted() {
+		builderSelectOne = builderSelectOne.Where(notDeleted)
+	}
 
 	query, args, err := builderSelectOne.ToSql()
 	if err != nil {
@@ -67,44 +135,35 @@ func (s *server) Get(ctx context.Context, req *desc.GetRequest) (*desc.GetRespon
 		return nil, errors.New("failed to build query")
 	}
 
-	var id, author, price int32
-	var description, composition, photo_url, name string
-	var createdAt time.Time
-	var updatedAt time.Time
-
-	err = pool.QueryRow(ctx, query, args...).Scan(&id, &name, &price, &description, &composition, &author, &photo_url, &createdAt, &updatedAt)
+	n, err := scanDish(pool.QueryRow(ctx, query, args...))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			log.Printf("no note with such id in system: %d", err)
-			return nil, errors.New("no note with such id in system")
+			log.Printf("no dish with such id in system: %d", err)
+			return nil, errors.New("no dish with such id in system")
 		} else {
-			log.Printf("failed to select notes: %d", err)
-			return nil, errors.New("failed to select notes")
+			log.Printf("failed to select dishes: %d", err)
+			return nil, errors.New("failed to select dishes")
 		}
 	}
-	n := &desc.Dish{
-		Id: id,
-		Info: &desc.DishInfo{
-			Name:        name,
-			Price:       price,
-			Description: description,
-			Composition: composition,
-			Author:      author,
-			PhotoUrl:    photo_url,
-		},
-		CreatedAt: timestamppb.New(createdAt),
-		UpdatedAt: timestamppb.New(updatedAt),
+
+	promos, err := loadPromotions(ctx, pool, "")
+	if err != nil {
+		return nil, err
+	}
+	applyDishPromotions([]*desc.Dish{n}, promos)
+
+	groups, err := loadModifierGroups(ctx, pool, []int32{n.GetId()})
+	if err != nil {
+		return nil, err
 	}
+	n.ModifierGroups = groups[n.GetId()]
+
 	return &desc.GetResponse{
 		Note: n,
 	}, nil
 }
 
 func (s *server) Create(ctx context.Context, req *desc.CreateRequest) (*desc.CreateResponse, error) {
-	var id int32
-	a := req.GetInfo().GetAuthor()
-	var flag bool = true
-
 	pool, err := pgxpool.Connect(ctx, dbDSN)
 	if err != nil {
 		log.Printf("failed to connect to database: %d", err)
@@ -112,63 +171,66 @@ func (s *server) Create(ctx context.Context, req *desc.CreateRequest) (*desc.Cre
 	}
 	defer pool.Close()
 
-	for flag {
-		id = int32(gofakeit.Uint16())
-		builderSelect := squirrel.Select("id").
-			From("note").
-			PlaceholderFormat(squirrel.Dollar).
-			Where(squirrel.Eq{"id": id})
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
 
-		query, args, err := builderSelect.ToSql()
-		if err != nil {
-			log.Printf("failed to build query: %d", err)
-			return nil, errors.New("failed to build query")
-		}
+	id, err := insertDish(ctx, tx, req.GetInfo())
+	if err != nil {
+		return nil, err
+	}
 
-		err = pool.QueryRow(ctx, query, args...).Scan(&id)
-		if errors.Is(err, pgx.ErrNoRows) {
-			flag = false
-		}
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert dish")
 	}
+	return &desc.CreateResponse{Id: id}, nil
+}
 
-	builderSelect := squirrel.Select("id").
-		From("persons").
+// insertDish creates a dish and records the mutation, it must be called in a transaction.
+func insertDish(ctx context.Context, tx pgx.Tx, info *desc.DishInfo) (int32, error) {
+	// Author 0 is a dish without an author, as dishes are read with COALESCE(author, 0).
+	var author interface{}
+	if info.GetAuthor() != 0 {
+		author = info.GetAuthor()
+	}
+
+	builderInsert := squirrel.Insert("dishes").
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"id": a})
+		Columns("name", "price", "description", "composition", "author", "photo_url", "category", "allergens", "created_at", "updated_at").
+		Values(info.GetName(), info.GetPrice(), info.GetDescription(), info.GetComposition(), author, info.GetPhotoUrl(), info.GetCategory(), append([]string{}, info.GetAllergens()...), sqlNow, sqlNow).
+		Suffix("RETURNING id")
 
-	query, args, err := builderSelect.ToSql()
+	query, args, err := builderInsert.ToSql()
 	if err != nil {
 		log.Printf("failed to build query: %d", err)
-		return nil, errors.New("failed to build query")
+		return 0, errors.New("failed to build query")
 	}
 
-	err = pool.QueryRow(ctx, query, args...).Scan(&a)
-	if err != nil {
-		log.Printf("error to use this user id: %d", err)
-		return nil, errors.New("error to use this user id")
+	var id int32
+	if err = tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		return 0, constraintError(err, "failed to insert dish")
 	}
-	builderInsert := squirrel.Insert("note").
-		PlaceholderFormat(squirrel.Dollar).
-		Columns("id", "name", "price", "description", "composition", "author", "photo_url", "created_at", "updated_at").
-		Values(id, req.GetInfo().GetName(), req.GetInfo().GetPrice(), a, req.GetInfo().GetDescription(), req.GetInfo().GetComposition(), req.GetInfo().GetAuthor(), req.GetInfo().GetPhotoUrl(), time.Now().Format("2006-01-02 15:04:05.999999"), time.Now().Format("2006-01-02 15:04:05.999999"))
 
-	query, args, err = builderInsert.ToSql()
+	after, err := rowSnapshot(ctx, tx, "dishes", id)
 	if err != nil {
-		log.Printf("failed to build query: %d", err)
-		return nil, errors.New("failed to build query")
+		return 0, err
 	}
-
-	var noteID int
-	err = pool.QueryRow(ctx, query, args...).Scan(&noteID)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		log.Printf("failed to insert note: %d", err)
-		return nil, errors.New("failed to insert note")
+	if err = recordMutation(ctx, tx, "dishes", id, "create", nil, after); err != nil {
+		return 0, err
 	}
-
-	return &desc.CreateResponse{Id: id}, nil
+	return id, nil
 }
 
 func (s *server) Update(ctx context.Context, req *desc.UpdateRequest) (*emptypb.Empty, error) {
+	dish, paths, err := dishUpdate(req)
+	if err != nil {
+		return nil, err
+	}
+
 	pool, err := pgxpool.Connect(ctx, dbDSN)
 	if err != nil {
 		log.Printf("failed to connect to database: %d", err)
@@ -176,103 +238,55 @@ func (s *server) Update(ctx context.Context, req *desc.UpdateRequest) (*emptypb.
 	}
 	defer pool.Close()
 
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
 	reqId := req.GetId()
 
-	builderSelect := squirrel.Select("id", "name", "price", "description", "composition", "author", "photo_url").
-		From("note").
+	builderSelect := squirrel.Select("updated_at", "version").
+		From("dishes").
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"id": reqId})
+		Where(squirrel.Eq{"id": reqId}).
+		Where(notDeleted).
+		Suffix("FOR UPDATE")
 
 	query, args, err := builderSelect.ToSql()
 	if err != nil {
 		log.Printf("failed to build query: %d", err)
 		return nil, errors.New("failed to build query")
 	}
-	var id, author, price int64
-	var description, composition, photo_url, name string
-
-	err = pool.QueryRow(ctx, query, args...).Scan(&id, &name, &price, &description, &composition, &author, &photo_url)
-	if errors.Is(err, pgx.ErrNoRows) {
-		log.Printf("there is no note with such id in system: %d", err)
-		return nil, errors.New("there is no note with such id in system")
-	}
-
-	if req.GetInfo().GetAuthor() != nil {
-		auth := req.GetInfo().GetAuthor().GetValue()
-
-		builderSelect = squirrel.Select("id").
-			From("persons").
-			PlaceholderFormat(squirrel.Dollar).
-			Where(squirrel.Eq{"id": auth})
+	var version int32
+	var updatedAt time.Time
 
-		query, args, err = builderSelect.ToSql()
-		if err != nil {
-			log.Printf("failed to build query: %d", err)
-			return nil, errors.New("failed to build query")
-		}
-		err = pool.QueryRow(ctx, query, args...).Scan(&auth)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				log.Printf("there is no person with such id in system: %d", err)
-				return nil, errors.New("there is no person with such id in system")
-			} else {
-				log.Printf("failed to find user with this id: %d", err)
-				return nil, errors.New("failed to find user with this id")
-			}
+	err = tx.QueryRow(ctx, query, args...).Scan(&updatedAt, &version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("there is no dish with such id in system: %v", err)
+			return nil, errors.New("there is no dish with such id in system")
 		}
-	}
-	var newName string
-	if req.GetInfo().GetName() != nil {
-		newName = req.GetInfo().GetName().GetValue()
-	} else {
-		newName = name
-	}
-
-	var newPrice int64
-	if req.GetInfo().GetPrice() != nil {
-		newPrice = req.GetInfo().GetPrice().GetValue()
-	} else {
-		newPrice = price
-	}
-
-	var newDescription string
-	if req.GetInfo().GetDescription() != nil {
-		newDescription = req.GetInfo().GetDescription().GetValue()
-	} else {
-		newDescription = description
+		log.Printf("failed to select dish: %v", err)
+		return nil, errors.New("failed to select dish")
 	}
 
-	var newComposition string
-	if req.GetInfo().GetComposition() != nil {
-		newComposition = req.GetInfo().GetComposition().GetValue()
-	} else {
-		newComposition = composition
+	if err = checkExpectedVersion(req, version, updatedAt); err != nil {
+		return nil, err
 	}
 
-	var newAuthor int64
-	if req.GetInfo().GetAuthor() != nil {
-		newAuthor = req.GetInfo().GetAuthor().GetValue()
-	} else {
-		newAuthor = author
-	}
-
-	var newPhotoUrl string
-	if req.GetInfo().GetPhotoUrl() != nil {
-		newPhotoUrl = req.GetInfo().GetPhotoUrl().GetValue()
-	} else {
-		newPhotoUrl = photo_url
+	before, err := rowSnapshot(ctx, tx, "dishes", reqId)
+	if err != nil {
+		return nil, err
 	}
 
-	builderUpdate := squirrel.Update("note").
+	builderUpdate := squirrel.Update("dishes").
 		PlaceholderFormat(squirrel.Dollar).
-		Set("name", newName).
-		Set("price", newPrice).
-		Set("description", newDescription).
-		Set("composition", newComposition).
-		Set("author", newAuthor).
-		Set("phoyo_url", newPhotoUrl).
-		Set("updated_at", time.Now().Format("2006-01-02 15:04:05.999999")).
+		Set("updated_at", sqlNow).
+		Set("version", squirrel.Expr("version + 1")).
 		Where(squirrel.Eq{"id": reqId})
+	builderUpdate = setMaskedColumns(builderUpdate, dish, paths)
 
 	query, args, err = builderUpdate.ToSql()
 	if err != nil {
@@ -280,13 +294,35 @@ func (s *server) Update(ctx context.Context, req *desc.UpdateRequest) (*emptypb.
 		return nil, errors.New("failed to build query")
 	}
 
-	res, err := pool.Exec(ctx, query, args...)
-	log.Print(res)
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return nil, constraintError(err, "failed to update dish information")
+	}
+
+	after, err := rowSnapshot(ctx, tx, "dishes", reqId)
 	if err != nil {
-		log.Printf("failed to update note information: %d", err)
-		return nil, errors.New("failed to update note information")
+		return nil, err
+	}
+	if err = recordMutation(ctx, tx, "dishes", reqId, "update", before, after); err != nil {
+		return nil, err
 	}
-	return nil, nil
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to update dish information")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// checkExpectedVersion returns Aborted when the dish no longer has the version or the update time
+// the request expects, an unset expectation accepts any.
+func checkExpectedVersion(req *desc.UpdateRequest, version int32, updatedAt time.Time) error {
+	if req.GetExpectedVersion() != nil && req.GetExpectedVersion().GetValue() != version {
+		return status.Errorf(codes.Aborted, "dish was modified concurrently, current version is %d", version)
+	}
+	if req.GetExpectedUpdatedAt() != nil && !req.GetExpectedUpdatedAt().AsTime().Equal(updatedAt) {
+		return status.Error(codes.Aborted, "dish was modified concurrently")
+	}
+	return nil
 }
 
 func (s *server) Delete(ctx context.Context, req *desc.DeleteRequest) (*emptypb.Empty, error) {
@@ -297,47 +333,68 @@ func (s *server) Delete(ctx context.Context, req *desc.DeleteRequest) (*emptypb.
 	}
 	defer pool.Close()
 
-	reqId := req.GetId()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
 
-	builderSelect := squirrel.Select("id").
-		From("note").
-		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"id": reqId})
+	if err = softDeleteDish(ctx, tx, req.GetId()); err != nil {
+		return nil, err
+	}
 
-	query, args, err := builderSelect.ToSql()
-	if err != nil {
-		log.Printf("failed to build query: %d", err)
-		return nil, errors.New("failed to build query")
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to delete dish")
 	}
-	var id int64
-	err = pool.QueryRow(ctx, query, args...).Scan(&id)
-	if errors.Is(err, pgx.ErrNoRows) {
-		log.Printf("there is no note with such id in system: %d", err)
-		return nil, errors.New("there is no note with such id in system")
+	return &emptypb.Empty{}, nil
+}
+
+// softDeleteDish marks a dish as deleted and records the mutation, it must be called in a transaction.
+func softDeleteDish(ctx context.Context, tx pgx.Tx, id int32) error {
+	before, err := rowSnapshot(ctx, tx, "dishes", id)
+	if err != nil {
+		return err
 	}
 
-	builderDelete := squirrel.Delete("id").
-		From("note").
+	builderDelete := squirrel.Update("dishes").
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"id": reqId})
+		Set("deleted_at", sqlNow).
+		Where(squirrel.Eq{"id": id}).
+		Where(notDeleted)
 
-	query, args, err = builderDelete.ToSql()
+	query, args, err := builderDelete.ToSql()
 	if err != nil {
 		log.Printf("failed to build query: %d", err)
-		return nil, errors.New("failed to build query")
+		return errors.New("failed to build query")
 	}
 
-	err = pool.QueryRow(ctx, query, args...).Scan(&id)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		log.Printf("failed to delete note: %d", err)
-		return nil, errors.New("failed to delete note")
+	res, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete dish: %v", err)
+		return errors.New("failed to delete dish")
+	}
+	if res.RowsAffected() == 0 {
+		return status.Error(codes.NotFound, "there is no dish with such id in system")
 	}
-	return nil, nil
+
+	after, err := rowSnapshot(ctx, tx, "dishes", id)
+	if err != nil {
+		return err
+	}
+	return recordMutation(ctx, tx, "dishes", id, "delete", before, after)
 }
 
 func (s *server) List(ctx context.Context, req *desc.ListRequest) (*desc.ListResponse, error) {
 	curr := make([]*desc.Dish, 0)
 
+	if req.GetFavouriteOf() != nil {
+		if _, err := favouritesOwner(ctx, req.GetFavouriteOf().GetValue()); err != nil {
+			return nil, err
+		}
+	}
+
 	pool, err := pgxpool.Connect(ctx, dbDSN)
 	if err != nil {
 		log.Printf("failed to connect to database: %d", err)
@@ -345,51 +402,63 @@ func (s *server) List(ctx context.Context, req *desc.ListRequest) (*desc.ListRes
 	}
 	defer pool.Close()
 
-	builderSelect := squirrel.Select("id", "name", "price", "description", "composition", "author", "photo_url", "created_at", "updated_at").
-		From("note").
-		PlaceholderFormat(squirrel.Dollar)
+	if req.GetShowDeleted() {
+		if err = requireManager(ctx, pool); err != nil {
+			return nil, err
+		}
+	}
 
-	query, args, err := builderSelect.ToSql()
+	query, args, err := listDishesSelect(req).ToSql()
 	if err != nil {
 		log.Printf("failed to build query: %d", err)
 		return nil, errors.New("failed to build query")
 	}
 
-	var id, author, price int32
-	var description, composition, photo_url, name string
-	var createdAt time.Time
-	var updatedAt time.Time
-
 	rows, err := pool.Query(ctx, query, args...)
-	if errors.Is(err, pgx.ErrNoRows) {
-		log.Printf("there is no notes made by this person: %d", err)
-		return nil, errors.New("there is no notes made by this person")
+	if err != nil {
+		log.Printf("failed to select dishes: %v", err)
+		return nil, errors.New("failed to select dishes")
 	}
 
 	for rows.Next() {
-		err = rows.Scan(&id, &name, &price, &description, &composition, &author, &photo_url, &createdAt, &updatedAt)
+		n, err := scanDish(rows)
 		if err != nil {
-			log.Printf("failed to scan note: %d", err)
-			return nil, errors.New("failed to scan note")
-		}
-		n := &desc.Dish{
-			Id: id,
-			Info: &desc.DishInfo{
-				Name:        name,
-				Price:       price,
-				Description: description,
-				Composition: composition,
-				Author:      author,
-				PhotoUrl:    photo_url,
-			},
-			CreatedAt: timestamppb.New(createdAt),
-			UpdatedAt: timestamppb.New(updatedAt),
+			rows.Close()
+			log.Printf("failed to scan dish: %v", err)
+			return nil, errors.New("failed to scan dish")
 		}
 		curr = append(curr, n)
 	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read dishes: %v", err)
+		return nil, errors.New("failed to read dishes")
+	}
+
+	promos, err := loadPromotions(ctx, pool, "")
+	if err != nil {
+		return nil, err
+	}
+	applyDishPromotions(curr, promos)
+
 	return &desc.ListResponse{Dishes: curr}, nil
 }
 
+// listDishesSelect selects the dishes of List, dishes in the trash only when show_deleted is set.
+func listDishesSelect(req *desc.ListRequest) squirrel.SelectBuilder {
+	builderSelect := squirrel.Select(dishColumns...).
+		From("dishes").
+		PlaceholderFormat(squirrel.Dollar)
+	if !req.GetShowDeleted() {
+		builderSelect = builderSelect.Where(notDeleted)
+	}
+
+	if req.GetFavouriteOf() != nil {
+		builderSelect = builderSelect.Where("id IN (SELECT dish_id FROM favourites WHERE person_id = ?)", req.GetFavouriteOf().GetValue())
+	}
+	return builderSelect
+}
+
 func (s *server) CreatePerson(ctx context.Context, req *desc.CreatePersonReqest) (*desc.CreatePersonResponse, error) {
 	pool, err := pgxpool.Connect(ctx, dbDSN)
 	if err != nil {
@@ -397,60 +466,45 @@ func (s *server) CreatePerson(ctx context.Context, req *desc.CreatePersonReqest)
 	}
 	defer pool.Close()
 
-	author := int32(gofakeit.Uint16())
-	flag := true
-	for flag {
-		builderSelect := squirrel.Select("id").
-			From("persons").
-			PlaceholderFormat(squirrel.Dollar).
-			Where(squirrel.Eq{"id": author})
-
-		query, args, err := builderSelect.ToSql()
-		if err != nil {
-			log.Fatalf("failed to build query: %v", err)
-		}
-		var id int32
-		err = pool.QueryRow(ctx, query, args...).Scan(&id)
-		if errors.Is(err, pgx.ErrNoRows) {
-			author = int32(gofakeit.Uint16())
-			flag = false
-		}
+	if err = checkNewPersonPosition(ctx, pool, req.GetPosition()); err != nil {
+		return nil, err
 	}
 
-	login := req.GetLogin()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
 
-	builderSelect := squirrel.Select("id").
-		From("persons").
+	builderInsert := squirrel.Insert("persons").
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"login": login})
+		Columns("login", "password", "position").
+		Values(req.GetLogin(), req.GetPassword(), req.GetPosition()).
+		Suffix("RETURNING id")
 
-	query, args, err := builderSelect.ToSql()
+	query, args, err := builderInsert.ToSql()
 	if err != nil {
 		log.Printf("failed to build query: %d", err)
 		return nil, errors.New("failed to build query")
 	}
-	var id int64
-	err = pool.QueryRow(ctx, query, args...).Scan(&id)
-	if !(errors.Is(err, pgx.ErrNoRows)) {
-		log.Printf("there is a person with such login in system: %d", err)
-		return nil, errors.New("there is a person with such login in system")
-	}
 
-	builderInsert := squirrel.Insert("persons").
-		PlaceholderFormat(squirrel.Dollar).
-		Columns("id", "login", "password", "position").
-		Values(author, req.GetLogin(), req.GetPassword(), req.GetPosition())
+	var author int32
+	if err = tx.QueryRow(ctx, query, args...).Scan(&author); err != nil {
+		return nil, constraintError(err, "failed to insert person")
+	}
 
-	query, args, err = builderInsert.ToSql()
-	if !errors.Is(err, pgx.ErrNoRows) && (err != nil) {
-		log.Printf("failed to build query: %d", err)
-		return nil, errors.New("failed to build query")
+	after, err := rowSnapshot(ctx, tx, "persons", author)
+	if err != nil {
+		return nil, err
+	}
+	if err = recordMutation(ctx, tx, "persons", author, "create", nil, after); err != nil {
+		return nil, err
 	}
 
-	err = pool.QueryRow(ctx, query, args...).Scan(&id)
-	if !errors.Is(err, pgx.ErrNoRows) && err != nil {
-		log.Printf("failed to insert note: %d", err)
-		return nil, errors.New("failed to insert note")
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert person")
 	}
 	return &desc.CreatePersonResponse{Id: author}, nil
 }
@@ -467,7 +521,7 @@ func (s *server) LogInPerson(ctx context.Context, req *desc.LogInPersonRequest)
 	builderSelect := squirrel.Select("id", "login", "password", "position").
 		From("persons").
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"login": login})
+		Where("lower(login) = lower(?)", login)
 
 	query, args, err := builderSelect.ToSql()
 	if err != nil {
@@ -486,9 +540,18 @@ func (s *server) LogInPerson(ctx context.Context, req *desc.LogInPersonRequest)
 
 	if password != req.GetPassword() {
 		return nil, errors.New("incorrect password")
-	} else {
-		return &desc.LogInPersonResponce{Id: id, Position: position}, nil
 	}
+	token, expiresAt, err := issueToken(id, time.Now())
+	if err != nil {
+		log.Printf("failed to issue token: %v", err)
+		return nil, errors.New("failed to issue token")
+	}
+	return &desc.LogInPersonResponce{
+		Id:             id,
+		Position:       position,
+		Token:          token,
+		TokenExpiresAt: timestamppb.New(expiresAt),
+	}, nil
 }
 
 func (s *server) ChangePersonPosition(ctx context.Context, req *desc.ChangePersonPositionRequest) (*desc.ChangePersonPositionResponse, error) {
@@ -499,27 +562,25 @@ func (s *server) ChangePersonPosition(ctx context.Context, req *desc.ChangePerso
 	}
 	defer pool.Close()
 
-	auth := req.GetId()
-
-	builderSelect := squirrel.Select("id").
-		From("persons").
-		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"id": auth})
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
 
-	query, args, err := builderSelect.ToSql()
+	tx, err := pool.Begin(ctx)
 	if err != nil {
-		log.Printf("failed to build query: %d", err)
-		return nil, errors.New("failed to build query")
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
 	}
-	err = pool.QueryRow(ctx, query, args...).Scan(&auth)
+	defer tx.Rollback(ctx)
+
+	auth := req.GetId()
+
+	before, err := rowSnapshot(ctx, tx, "persons", auth)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			log.Printf("there is no person with such id in system: %d", err)
-			return nil, errors.New("there is no person with such id in system")
-		} else {
-			log.Printf("failed to find user with this id: %d", err)
-			return nil, errors.New("failed to find user with this id")
-		}
+		return nil, err
+	}
+	if before == nil {
+		return nil, errors.New("there is no person with such id in system")
 	}
 
 	builderUpdate := squirrel.Update("persons").
@@ -527,30 +588,64 @@ func (s *server) ChangePersonPosition(ctx context.Context, req *desc.ChangePerso
 		Set("position", req.GetPosition()).
 		Where(squirrel.Eq{"id": auth})
 
-	query, args, err = builderUpdate.ToSql()
+	query, args, err := builderUpdate.ToSql()
 	if err != nil {
 		log.Printf("failed to build query: %d", err)
 		return nil, errors.New("failed to build query")
 	}
 
-	res, err := pool.Exec(ctx, query, args...)
+	res, err := tx.Exec(ctx, query, args...)
 	log.Print(res)
 	if err != nil {
 		log.Printf("failed to update person information: %d", err)
 		return nil, errors.New("failed to update person information")
 	}
+
+	after, err := rowSnapshot(ctx, tx, "persons", auth)
+	if err != nil {
+		return nil, err
+	}
+	if err = recordMutation(ctx, tx, "persons", auth, "change_position", before, after); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to update person information")
+	}
 	return &desc.ChangePersonPositionResponse{Position: req.GetPosition()}, nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+	prepareSchema()
+	authSecret = loadAuthSecret()
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
+	// The gateway keeps one long-lived connection and pings it every 30 seconds.
+	s := grpc.NewServer(
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             20 * time.Second,
+			PermitWithoutStream: true,
+		}),
+		grpc.ChainUnaryInterceptor(requestIDInterceptor, authInterceptor, validationInterceptor),
+	)
 	reflection.Register(s)
 	desc.RegisterDishV1Server(s, &server{})
+	reservationDesc.RegisterReservationV1Server(s, &reservationServer{})
+	serveGRPCWeb(s)
+	startPurgeJob()
+	startOutboxRelay()
+	startWebhookWorker()
 
 	log.Printf("server listening at %v", lis.Addr())
 
@@ -0,0 +1,38 @@
+package main
+
+import (
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+	"time"
+)
+
+func TestCheckExpectedVersion(t *testing.T) {
+	updatedAt := time.Date(2026, 3, 1, 21, 30, 0, 123456000, time.UTC)
+	tests := []struct {
+		name string
+		req  *desc.UpdateRequest
+		code codes.Code
+	}{
+		{"no expectation", &desc.UpdateRequest{}, codes.OK},
+		{"current version", &desc.UpdateRequest{ExpectedVersion: wrapperspb.Int32(3)}, codes.OK},
+		{"stale version", &desc.UpdateRequest{ExpectedVersion: wrapperspb.Int32(2)}, codes.Aborted},
+		{"future version", &desc.UpdateRequest{ExpectedVersion: wrapperspb.Int32(4)}, codes.Aborted},
+		{"zero version", &desc.UpdateRequest{ExpectedVersion: wrapperspb.Int32(0)}, codes.Aborted},
+		{"current update time", &desc.UpdateRequest{ExpectedUpdatedAt: timestamppb.New(updatedAt)}, codes.OK},
+		{"same instant in another zone", &desc.UpdateRequest{ExpectedUpdatedAt: timestamppb.New(updatedAt.In(time.FixedZone("MSK", 3*60*60)))}, codes.OK},
+		{"stale update time", &desc.UpdateRequest{ExpectedUpdatedAt: timestamppb.New(updatedAt.Add(-time.Microsecond))}, codes.Aborted},
+		{"current version, stale update time", &desc.UpdateRequest{
+			ExpectedVersion:   wrapperspb.Int32(3),
+			ExpectedUpdatedAt: timestamppb.New(updatedAt.Add(-time.Second)),
+		}, codes.Aborted},
+	}
+	for _, tt := range tests {
+		if err := checkExpectedVersion(tt.req, 3, updatedAt); status.Code(err) != tt.code {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Migrations are goose compatible SQL files named <version>_<name>.sql with "-- +goose Up"
+// and "-- +goose Down" sections. Applied versions are recorded in schema_migrations.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const (
+	migrateOnStartEnv = "MIGRATE_ON_START"
+	schemaCheckEnv    = "SCHEMA_CHECK"
+	migrationsTable   = "schema_migrations"
+	// baselineScratchSchema holds the tables of the baseline migration while checkAdoptedTables compares them.
+	baselineScratchSchema = "schema_migrations_baseline"
+	// migrationLockID serialises servers migrating the same database at once.
+	migrationLockID = 7717
+)
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+// expectedColumns lists the columns the server reads and writes, checkSchema refuses
+// to start the server against a database which lacks any of them.
+var expectedColumns = map[string][]string{
+	"dishes":                {"id", "name", "price", "description", "composition", "author", "photo_url", "category", "created_at", "updated_at", "version", "deleted_at", "allergens"},
+	"persons":               {"id", "login", "password", "position"},
+	"favourites":            {"person_id", "dish_id", "created_at"},
+	"collections":           {"id", "person_id", "name", "created_at"},
+	"collection_dishes":     {"collection_id", "dish_id"},
+	"promotions":            {"id", "name", "code", "kind", "value", "target", "category", "valid_from", "valid_to", "usage_limit", "used", "created_at"},
+	"promotion_dishes":      {"promotion_id", "dish_id"},
+	"orders":                {"id", "person_id", "subtotal", "discount", "total", "promo_code", "created_at"},
+	"order_lines":           {"order_id", "position", "dish_id", "combo_id", "combo_choices", "modifier_ids", "quantity", "unit_price", "discount", "total", "promotion_id", "promotion_name", "explanation"},
+	"order_promotions":      {"order_id", "promotion_id", "name", "discount", "explanation"},
+	"combos":                {"id", "name", "description", "price", "available", "created_at", "updated_at"},
+	"combo_slots":           {"combo_id", "position", "name"},
+	"combo_slot_dishes":     {"combo_id", "position", "dish_id"},
+	"modifier_groups":       {"id", "dish_id", "name", "multiple", "min_selections", "max_selections"},
+	"modifiers":             {"id", "group_id", "name", "price_delta"},
+	"tables":                {"id", "name", "capacity", "area"},
+	"reservations":          {"id", "table_id", "person_id", "guest_name", "guests", "starts_at", "ends_at", "comment", "cancelled", "created_at"},
+	"audit_events":          {"id", "actor_id", "authenticated", "occurred_at", "entity", "entity_id", "action", "before", "after", "request_id"},
+	"outbox":                {"id", "event_type", "aggregate", "aggregate_id", "payload", "occurred_at", "published_at", "attempts", "last_error", "next_attempt_at", "locked_until", "dead_at"},
+	"webhook_subscriptions": {"id", "url", "event_types", "secret", "created_at"},
+	"webhook_deliveries":    {"id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts", "last_status_code", "last_error", "next_attempt_at", "delivered_at", "created_at", "locked_until"},
+}
+
+// loadMigrations parses the embedded migrations in version order.
+func loadMigrations() ([]migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, name := range names {
+		base := path.Base(name)
+		prefix, _, found := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !found || err != nil {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.sql", base)
+		}
+		content, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		m := migration{version: version, name: strings.TrimSuffix(base, ".sql")}
+		var section *string
+		for _, line := range strings.SplitAfter(string(content), "\n") {
+			switch strings.TrimSpace(line) {
+			case "-- +goose Up":
+				section = &m.up
+			case "-- +goose Down":
+				section = &m.down
+			default:
+				if section != nil {
+					*section += line
+				}
+			}
+		}
+		if strings.TrimSpace(m.up) == "" {
+			return nil, fmt.Errorf("migration %s has no up section", base)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", migrations[i-1].name, migrations[i].name)
+		}
+	}
+	return migrations, nil
+}
+
+// appliedMigrations returns the applied versions and when they were applied.
+func appliedMigrations(ctx context.Context, conn *pgx.Conn) (map[int64]time.Time, error) {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func applyMigration(ctx context.Context, conn *pgx.Conn, m migration, up bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	script, record := m.up, "INSERT INTO "+migrationsTable+" (version, name) VALUES ($1, $2)"
+	args := []interface{}{m.version, m.name}
+	if !up {
+		script, record = m.down, "DELETE FROM "+migrationsTable+" WHERE version = $1"
+		args = args[:1]
+	}
+	if strings.TrimSpace(script) != "" {
+		if _, err = tx.Exec(ctx, script); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+	}
+	if _, err = tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// withMigrationLock runs f on a connection holding the migration advisory lock.
+func withMigrationLock(ctx context.Context, f func(conn *pgx.Conn) error) error {
+	conn, err := pgx.Connect(ctx, dbDSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	defer conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+	return f(conn)
+}
+
+// migrateUp applies every pending migration.
+func migrateUp(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	return withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i, m := range migrations {
+			if _, ok := applied[m.version]; ok {
+				continue
+			}
+			if i == 0 {
+				if err = checkAdoptedTables(ctx, conn, m); err != nil {
+					return err
+				}
+			}
+			if err = applyMigration(ctx, conn, m, true); err != nil {
+				return err
+			}
+			log.Printf("applied migration %s", m.name)
+		}
+		return nil
+	})
+}
+
+// checkAdoptedTables returns an error when the database already has tables which the baseline
+// migration would adopt but whose columns differ from the ones it creates. The migration is run in
+// a scratch schema which is rolled back, and its tables are compared with the existing ones.
+func checkAdoptedTables(ctx context.Context, conn *pgx.Conn, baseline migration) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var schema string
+	if err = tx.QueryRow(ctx, "SELECT current_schema()").Scan(&schema); err != nil {
+		return err
+	}
+	scratch := pgx.Identifier{baselineScratchSchema}.Sanitize()
+	if _, err = tx.Exec(ctx, "CREATE SCHEMA "+scratch); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, "SET LOCAL search_path TO "+scratch+", "+pgx.Identifier{schema}.Sanitize()); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, baseline.up); err != nil {
+		return fmt.Errorf("migration %s: %w", baseline.name, err)
+	}
+
+	// Every column of a table present in both schemas, with its type and nullability on each side.
+	rows, err := tx.Query(ctx, `SELECT coalesce(w.table_name, e.table_name), coalesce(w.column_name, e.column_name),
+       coalesce(w.data_type, ''), coalesce(w.is_nullable, ''), coalesce(e.data_type, ''), coalesce(e.is_nullable, '')
+FROM (SELECT * FROM information_schema.columns WHERE table_schema = $1) w
+FULL JOIN (SELECT * FROM information_schema.columns WHERE table_schema = $2) e
+    ON e.table_name = w.table_name AND e.column_name = w.column_name
+WHERE coalesce(w.table_name, e.table_name) IN (SELECT table_name FROM information_schema.tables WHERE table_schema = $1)
+  AND coalesce(w.table_name, e.table_name) IN (SELECT table_name FROM information_schema.tables WHERE table_schema = $2)
+ORDER BY 1, 2`, baselineScratchSchema, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var table, column, wantType, wantNullable, gotType, gotNullable string
+		if err = rows.Scan(&table, &column, &wantType, &wantNullable, &gotType, &gotNullable); err != nil {
+			return err
+		}
+		switch {
+		case gotType == "":
+			problems = append(problems, "column "+table+"."+column+" is missing")
+		case wantType == "":
+			problems = append(problems, "column "+table+"."+column+" is not expected")
+		case gotType != wantType:
+			problems = append(problems, "column "+table+"."+column+" is "+gotType+", expected "+wantType)
+		case gotNullable != wantNullable:
+			problems = append(problems, "column "+table+"."+column+" nullability differs")
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("existing tables do not match migration %s, fix or drop them before migrating: %s", baseline.name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// migrateDown rolls back the latest applied migration.
+func migrateDown(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	return withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[migrations[i].version]; !ok {
+				continue
+			}
+			if err = applyMigration(ctx, conn, migrations[i], false); err != nil {
+				return err
+			}
+			log.Printf("rolled back migration %s", migrations[i].name)
+			return nil
+		}
+		return errors.New("no migrations to roll back")
+	})
+}
+
+func migrateStatus(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	conn, err := pgx.Connect(ctx, dbDSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, m := range migrations {
+		appliedAt := "pending"
+		if t, ok := applied[m.version]; ok {
+			appliedAt = t.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.version, m.name, appliedAt)
+	}
+	return w.Flush()
+}
+
+// checkSchema returns an error when a migration is pending or an expected column is missing.
+func checkSchema(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	conn, err := pgx.Connect(ctx, dbDSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; !ok {
+			problems = append(problems, "migration "+m.name+" is not applied")
+		}
+	}
+
+	rows, err := conn.Query(ctx, "SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema()")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err = rows.Scan(&table, &column); err != nil {
+			return err
+		}
+		columns[table+"."+column] = true
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	tables := make([]string, 0, len(expectedColumns))
+	for table := range expectedColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		for _, column := range expectedColumns[table] {
+			if !columns[table+"."+column] {
+				problems = append(problems, "column "+table+"."+column+" is missing")
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("schema does not match the server, run migrate up: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// runMigrateCommand implements "grpc_server migrate up|down|status".
+func runMigrateCommand(args []string) error {
+	ctx := context.Background()
+	if len(args) != 1 {
+		return errors.New("usage: grpc_server migrate up|down|status")
+	}
+	switch args[0] {
+	case "up":
+		return migrateUp(ctx)
+	case "down":
+		return migrateDown(ctx)
+	case "status":
+		return migrateStatus(ctx)
+	}
+	return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+}
+
+// prepareSchema migrates the database when MIGRATE_ON_START is true and checks the schema
+// unless SCHEMA_CHECK is false. When the check is enabled an unreachable database is fatal too,
+// the server does not start without knowing that the schema matches.
+func prepareSchema() {
+	ctx := context.Background()
+	if enabled, _ := strconv.ParseBool(os.Getenv(migrateOnStartEnv)); enabled {
+		if err := migrateUp(ctx); err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
+	}
+	enabled := true
+	if value := os.Getenv(schemaCheckEnv); value != "" {
+		var err error
+		if enabled, err = strconv.ParseBool(value); err != nil {
+			log.Fatalf("%s must be true or false: %v", schemaCheckEnv, err)
+		}
+	}
+	if !enabled {
+		log.Printf("%s is false, skipping schema check", schemaCheckEnv)
+		return
+	}
+	if err := checkSchema(ctx); err != nil {
+		log.Fatalf("failed to check database schema (set %s=false to skip the check): %v", schemaCheckEnv, err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// migrateTestDSNEnv names a PostgreSQL database in the keyword/value form of dbDSN, such as
+// "host=localhost dbname=note user=note-user password=note-password sslmode=disable".
+// TestMigrateInitSQL creates and drops a schema of its own there and is skipped without it.
+const migrateTestDSNEnv = "MIGRATE_TEST_DSN"
+
+// initSQL is the schema of the former course/init.sql, which deployed databases were created from.
+const initSQL = "testdata/init.sql"
+
+func TestBaselineIsInitSQL(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	data, err := os.ReadFile(initSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.version != int64(i+1) {
+			t.Errorf("migration %s, want version %d", m.name, i+1)
+		}
+	}
+	baseline := strings.ReplaceAll(migrations[0].up, "CREATE TABLE IF NOT EXISTS ", "CREATE TABLE ")
+	if strings.Join(strings.Fields(baseline), " ") != strings.Join(strings.Fields(string(data)), " ") {
+		t.Errorf("migration %s creates other tables than %s:\n%s", migrations[0].name, initSQL, migrations[0].up)
+	}
+}
+
+func TestMigrateInitSQL(t *testing.T) {
+	dsn := os.Getenv(migrateTestDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", migrateTestDSNEnv)
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err = conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+	saved := dbDSN
+	dbDSN = dsn + " search_path=" + schema
+	defer func() { dbDSN = saved }()
+
+	data, err := os.ReadFile(initSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.Exec(ctx, "SET search_path TO "+schema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.Exec(ctx, string(data)); err != nil {
+		t.Fatalf("init.sql: %v", err)
+	}
+	_, err = conn.Exec(ctx, `INSERT INTO persons (id, login, password) VALUES (1, 'cook', 'secret');
+INSERT INTO note (id, name, price, author, created_at) VALUES (1, 'Borscht', NULL, 1, '2024-05-01 12:00:00')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = migrateUp(ctx); err != nil {
+		t.Fatalf("migrate up from init.sql: %v", err)
+	}
+	if err = checkSchema(ctx); err != nil {
+		t.Fatalf("checkSchema after migrate up: %v", err)
+	}
+	var name string
+	var price int32
+	var createdAt time.Time
+	err = conn.QueryRow(ctx, "SELECT name, price, created_at FROM dishes WHERE id = 1").Scan(&name, &price, &createdAt)
+	if err != nil || name != "Borscht" || price != 0 || !createdAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("migrated dish: %q, %d, %v, %v", name, price, createdAt, err)
+	}
+	var fractional string
+	if _, err = conn.Exec(ctx, "UPDATE dishes SET price = 12.34 WHERE id = 1"); err != nil {
+		t.Fatalf("set a fractional price: %v", err)
+	}
+	if err = conn.QueryRow(ctx, "SELECT price::TEXT FROM dishes WHERE id = 1").Scan(&fractional); err != nil || fractional != "12.34" {
+		t.Errorf("fractional price: %q, %v, want exact 12.34", fractional, err)
+	}
+	if err = conn.QueryRow(ctx, "SELECT price FROM dishes WHERE id = 1").Scan(&price); err == nil {
+		t.Errorf("fractional price is read as %d, want an error", price)
+	}
+	if _, err = conn.Exec(ctx, "UPDATE dishes SET price = 0 WHERE id = 1"); err != nil {
+		t.Fatalf("reset the price: %v", err)
+	}
+
+	for {
+		if err = migrateDown(ctx); err != nil {
+			break
+		}
+	}
+	if err.Error() != "no migrations to roll back" {
+		t.Fatalf("migrate down: %v", err)
+	}
+	var tables int
+	if err = conn.QueryRow(ctx, "SELECT count(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name <> $2", schema, migrationsTable).Scan(&tables); err != nil || tables != 0 {
+		t.Errorf("%d tables left after rolling back every migration: %v", tables, err)
+	}
+	if err = migrateUp(ctx); err != nil {
+		t.Fatalf("migrate up after rolling back: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+-- Baseline schema, the to-do tables of the former course/init.sql. Tables are created only when
+-- missing, so databases initialised from init.sql are adopted. Before applying it migrate up compares
+-- existing tables with the ones created here and refuses to adopt tables which differ.
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note (
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    price INT,
+    description TEXT,
+    composition TEXT,
+    author INT,
+    photo_url TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS persons (
+    id INT NOT NULL,
+    login TEXT PRIMARY KEY,
+    password TEXT NOT NULL,
+    position TEXT NOT NULL DEFAULT 'user'
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS persons;
+DROP TABLE IF EXISTS note;
@@ -0,0 +1,232 @@
+-- Columns and tables of the dish service added to the to-do schema of init.sql: soft deletes and
+-- versions of dishes, favourites, promotions and orders, combos, modifiers, reservations, the audit
+-- log, the outbox and webhooks.
+-- +goose Up
+ALTER TABLE note
+    ADD COLUMN category TEXT NOT NULL DEFAULT '',
+    ADD COLUMN version INT NOT NULL DEFAULT 1,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE TABLE favourites (
+    person_id INT NOT NULL,
+    dish_id INT NOT NULL REFERENCES note (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (person_id, dish_id)
+);
+
+CREATE TABLE collections (
+    id INT PRIMARY KEY,
+    person_id INT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE collection_dishes (
+    collection_id INT NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    dish_id INT NOT NULL REFERENCES note (id) ON DELETE CASCADE,
+    PRIMARY KEY (collection_id, dish_id)
+);
+
+CREATE TABLE promotions (
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    code TEXT UNIQUE,
+    kind TEXT NOT NULL,
+    value INT NOT NULL,
+    target TEXT NOT NULL,
+    category TEXT,
+    valid_from TIMESTAMP,
+    valid_to TIMESTAMP,
+    usage_limit INT NOT NULL DEFAULT 0,
+    used INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE promotion_dishes (
+    promotion_id INT NOT NULL REFERENCES promotions (id) ON DELETE CASCADE,
+    dish_id INT NOT NULL REFERENCES note (id) ON DELETE CASCADE,
+    PRIMARY KEY (promotion_id, dish_id)
+);
+
+CREATE TABLE orders (
+    id INT PRIMARY KEY,
+    person_id INT NOT NULL,
+    subtotal INT NOT NULL,
+    discount INT NOT NULL,
+    total INT NOT NULL,
+    promo_code TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE order_lines (
+    order_id INT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    dish_id INT,
+    combo_id INT,
+    combo_choices INT[],
+    modifier_ids INT[],
+    quantity INT NOT NULL,
+    unit_price INT NOT NULL,
+    discount INT NOT NULL,
+    total INT NOT NULL,
+    promotion_id INT,
+    promotion_name TEXT,
+    explanation TEXT,
+    PRIMARY KEY (order_id, position)
+);
+
+CREATE TABLE order_promotions (
+    order_id INT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    promotion_id INT NOT NULL,
+    name TEXT NOT NULL,
+    discount INT NOT NULL,
+    explanation TEXT NOT NULL,
+    PRIMARY KEY (order_id, promotion_id)
+);
+
+CREATE TABLE combos (
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price INT NOT NULL,
+    available BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE combo_slots (
+    combo_id INT NOT NULL REFERENCES combos (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (combo_id, position)
+);
+
+CREATE TABLE combo_slot_dishes (
+    combo_id INT NOT NULL,
+    position INT NOT NULL,
+    dish_id INT NOT NULL REFERENCES note (id) ON DELETE CASCADE,
+    PRIMARY KEY (combo_id, position, dish_id),
+    FOREIGN KEY (combo_id, position) REFERENCES combo_slots (combo_id, position) ON DELETE CASCADE
+);
+
+CREATE TABLE modifier_groups (
+    id INT PRIMARY KEY,
+    dish_id INT NOT NULL REFERENCES note (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    multiple BOOLEAN NOT NULL DEFAULT FALSE,
+    min_selections INT NOT NULL DEFAULT 0,
+    max_selections INT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE modifiers (
+    id INT PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES modifier_groups (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    price_delta INT NOT NULL DEFAULT 0
+);
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE tables (
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    capacity INT NOT NULL CHECK (capacity > 0),
+    area TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE reservations (
+    id INT PRIMARY KEY,
+    table_id INT NOT NULL REFERENCES tables (id) ON DELETE CASCADE,
+    person_id INT,
+    guest_name TEXT NOT NULL DEFAULT '',
+    guests INT NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    cancelled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (starts_at < ends_at),
+    EXCLUDE USING gist (table_id WITH =, tsrange(starts_at, ends_at) WITH &&) WHERE (NOT cancelled)
+);
+
+CREATE INDEX reservations_starts_at_idx ON reservations (starts_at);
+
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    entity TEXT NOT NULL,
+    entity_id INT NOT NULL,
+    action TEXT NOT NULL,
+    before JSONB,
+    after JSONB,
+    request_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity, entity_id);
+CREATE INDEX audit_events_occurred_at_idx ON audit_events (occurred_at);
+
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    aggregate TEXT NOT NULL,
+    aggregate_id INT NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+CREATE TABLE webhook_subscriptions (
+    id INT PRIMARY KEY,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_status_code INT,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
+DROP TABLE outbox;
+DROP TABLE audit_events;
+DROP TABLE reservations;
+DROP TABLE tables;
+DROP TABLE modifiers;
+DROP TABLE modifier_groups;
+DROP TABLE combo_slot_dishes;
+DROP TABLE combo_slots;
+DROP TABLE combos;
+DROP TABLE order_promotions;
+DROP TABLE order_lines;
+DROP TABLE orders;
+DROP TABLE promotion_dishes;
+DROP TABLE promotions;
+DROP TABLE collection_dishes;
+DROP TABLE collections;
+DROP TABLE favourites;
+ALTER TABLE note
+    DROP COLUMN category,
+    DROP COLUMN version,
+    DROP COLUMN deleted_at;
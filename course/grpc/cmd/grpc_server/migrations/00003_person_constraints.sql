@@ -0,0 +1,40 @@
+-- persons.id becomes the primary key, logins stay unique regardless of case
+-- and dishes reference their author.
+-- +goose Up
+-- Persons used to be keyed by login, so ids and logins differing only in case may repeat.
+-- The migration stops and lists them instead of failing on the new constraints; merge or
+-- delete the duplicates, for example keeping the oldest account of each, and migrate again:
+--   SELECT id, login FROM persons WHERE id IN (SELECT id FROM persons GROUP BY id HAVING count(*) > 1);
+--   SELECT id, login FROM persons WHERE lower(login) IN (SELECT lower(login) FROM persons GROUP BY lower(login) HAVING count(*) > 1);
+-- +goose StatementBegin
+DO $$
+DECLARE
+    duplicate_ids TEXT;
+    duplicate_logins TEXT;
+BEGIN
+    SELECT string_agg(format('%s (%s)', id, logins), ', ') INTO duplicate_ids
+    FROM (SELECT id, string_agg(login, ', ' ORDER BY login) AS logins
+          FROM persons GROUP BY id HAVING count(*) > 1) d;
+    SELECT string_agg(format('%s (ids %s)', login, ids), ', ') INTO duplicate_logins
+    FROM (SELECT lower(login) AS login, string_agg(id::text, ', ' ORDER BY id) AS ids
+          FROM persons GROUP BY lower(login) HAVING count(*) > 1) d;
+    IF duplicate_ids IS NOT NULL OR duplicate_logins IS NOT NULL THEN
+        RAISE EXCEPTION 'persons must be deduplicated before this migration, duplicate ids: %; logins duplicated regardless of case: %',
+            coalesce(duplicate_ids, 'none'), coalesce(duplicate_logins, 'none');
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+ALTER TABLE persons DROP CONSTRAINT persons_pkey;
+ALTER TABLE persons ADD CONSTRAINT persons_pkey PRIMARY KEY (id);
+CREATE UNIQUE INDEX persons_login_key ON persons (lower(login));
+
+-- Dishes of persons which no longer exist lose their author instead of blocking the migration.
+UPDATE note SET author = NULL WHERE author IS NOT NULL AND author NOT IN (SELECT id FROM persons);
+ALTER TABLE note ADD CONSTRAINT note_author_fkey FOREIGN KEY (author) REFERENCES persons (id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE note DROP CONSTRAINT note_author_fkey;
+DROP INDEX persons_login_key;
+ALTER TABLE persons DROP CONSTRAINT persons_pkey;
+ALTER TABLE persons ADD CONSTRAINT persons_pkey PRIMARY KEY (login);
@@ -0,0 +1,84 @@
+-- Dishes move from the note table left over from the to-do project to dishes with proper types.
+-- The note view keeps servers of the previous release working during the rollout,
+-- it is simple enough for PostgreSQL to update it automatically.
+-- Prices are exact NUMERIC(12, 2). The API still carries whole prices in int32 fields, the server
+-- writes them unchanged and reading a price with a fractional part fails instead of rounding it.
+-- +goose Up
+CREATE TABLE dishes (
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    price NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    description TEXT NOT NULL DEFAULT '',
+    composition TEXT NOT NULL DEFAULT '',
+    author INT,
+    photo_url TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ
+);
+
+-- Timestamps were written in the server timezone, which is UTC in the containers.
+INSERT INTO dishes (id, name, price, description, composition, author, photo_url, category, created_at, updated_at, version, deleted_at)
+SELECT id, name, COALESCE(price, 0), COALESCE(description, ''), COALESCE(composition, ''), author, COALESCE(photo_url, ''), category,
+       created_at AT TIME ZONE 'UTC', updated_at AT TIME ZONE 'UTC', version, deleted_at AT TIME ZONE 'UTC'
+FROM note;
+
+ALTER TABLE dishes ADD CONSTRAINT dishes_author_fkey FOREIGN KEY (author) REFERENCES persons (id) ON DELETE SET NULL;
+CREATE INDEX dishes_category_idx ON dishes (category) WHERE deleted_at IS NULL;
+
+ALTER TABLE favourites DROP CONSTRAINT favourites_dish_id_fkey;
+ALTER TABLE favourites ADD CONSTRAINT favourites_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE;
+ALTER TABLE collection_dishes DROP CONSTRAINT collection_dishes_dish_id_fkey;
+ALTER TABLE collection_dishes ADD CONSTRAINT collection_dishes_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE;
+ALTER TABLE promotion_dishes DROP CONSTRAINT promotion_dishes_dish_id_fkey;
+ALTER TABLE promotion_dishes ADD CONSTRAINT promotion_dishes_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE;
+ALTER TABLE combo_slot_dishes DROP CONSTRAINT combo_slot_dishes_dish_id_fkey;
+ALTER TABLE combo_slot_dishes ADD CONSTRAINT combo_slot_dishes_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE;
+ALTER TABLE modifier_groups DROP CONSTRAINT modifier_groups_dish_id_fkey;
+ALTER TABLE modifier_groups ADD CONSTRAINT modifier_groups_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE;
+
+DROP TABLE note;
+
+CREATE VIEW note AS
+SELECT id, name, price, description, composition, author, photo_url, category, created_at, updated_at, version, deleted_at
+FROM dishes;
+
+-- +goose Down
+DROP VIEW note;
+
+CREATE TABLE note (
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    price INT,
+    description TEXT,
+    composition TEXT,
+    author INT,
+    photo_url TEXT,
+    category TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
+);
+
+INSERT INTO note (id, name, price, description, composition, author, photo_url, category, created_at, updated_at, version, deleted_at)
+SELECT id, name, round(price)::INT, description, composition, author, photo_url, category,
+       created_at AT TIME ZONE 'UTC', updated_at AT TIME ZONE 'UTC', version, deleted_at AT TIME ZONE 'UTC'
+FROM dishes;
+
+ALTER TABLE note ADD CONSTRAINT note_author_fkey FOREIGN KEY (author) REFERENCES persons (id) ON DELETE SET NULL;
+
+ALTER TABLE favourites DROP CONSTRAINT favourites_dish_id_fkey;
+ALTER TABLE favourites ADD CONSTRAINT favourites_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES note (id) ON DELETE CASCADE;
+ALTER TABLE collection_dishes DROP CONSTRAINT collection_dishes_dish_id_fkey;
+ALTER TABLE collection_dishes ADD CONSTRAINT collection_dishes_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES note (id) ON DELETE CASCADE;
+ALTER TABLE promotion_dishes DROP CONSTRAINT promotion_dishes_dish_id_fkey;
+ALTER TABLE promotion_dishes ADD CONSTRAINT promotion_dishes_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES note (id) ON DELETE CASCADE;
+ALTER TABLE combo_slot_dishes DROP CONSTRAINT combo_slot_dishes_dish_id_fkey;
+ALTER TABLE combo_slot_dishes ADD CONSTRAINT combo_slot_dishes_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES note (id) ON DELETE CASCADE;
+ALTER TABLE modifier_groups DROP CONSTRAINT modifier_groups_dish_id_fkey;
+ALTER TABLE modifier_groups ADD CONSTRAINT modifier_groups_dish_id_fkey FOREIGN KEY (dish_id) REFERENCES note (id) ON DELETE CASCADE;
+
+DROP TABLE dishes;
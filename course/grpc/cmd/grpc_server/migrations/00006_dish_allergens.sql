@@ -0,0 +1,6 @@
+-- Allergens are printed on menus, they are free-form names such as gluten or milk.
+-- +goose Up
+ALTER TABLE dishes ADD COLUMN allergens TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE dishes DROP COLUMN allergens;
@@ -0,0 +1,9 @@
+-- authenticated is true when the actor comes from a verified token, anonymous calls have no actor_id.
+-- Earlier rows stay false: their actor_id was taken from an unverified x-person-id header.
+-- +goose Up
+ALTER TABLE audit_events ADD COLUMN authenticated BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_authenticated_actor_check
+    CHECK (NOT authenticated OR actor_id IS NOT NULL);
+
+-- +goose Down
+ALTER TABLE audit_events DROP COLUMN authenticated;
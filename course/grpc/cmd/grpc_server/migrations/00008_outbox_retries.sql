@@ -0,0 +1,18 @@
+-- The relay leases events with locked_until, publishes them outside of the transaction and then
+-- records the result. Failed events wait for next_attempt_at, events which failed too often are
+-- dead-lettered with dead_at and no longer hold back the later events of their aggregate.
+-- +goose Up
+ALTER TABLE outbox
+    ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN locked_until TIMESTAMPTZ,
+    ADD COLUMN dead_at TIMESTAMPTZ;
+DROP INDEX IF EXISTS outbox_unpublished_idx;
+CREATE INDEX outbox_pending_idx ON outbox (aggregate, aggregate_id, id) WHERE published_at IS NULL AND dead_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS outbox_pending_idx;
+ALTER TABLE outbox
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN locked_until,
+    DROP COLUMN dead_at;
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
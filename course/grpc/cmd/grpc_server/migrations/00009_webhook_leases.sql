@@ -0,0 +1,9 @@
+-- The webhook worker leases due deliveries with locked_until and commits, posts them outside of
+-- the transaction and records the results in another one, so no row lock is held during HTTP calls.
+-- +goose Up
+ALTER TABLE webhook_deliveries
+    ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE webhook_deliveries
+    DROP COLUMN locked_until;
@@ -0,0 +1,38 @@
+-- Ids used to be picked at random by the server and collided once a table held a few hundred rows.
+-- The database generates them now, sequences start after the largest id in use.
+-- +goose Up
+ALTER TABLE persons ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('persons', 'id'), coalesce(max(id), 0) + 1, false) FROM persons;
+ALTER TABLE dishes ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('dishes', 'id'), coalesce(max(id), 0) + 1, false) FROM dishes;
+ALTER TABLE collections ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('collections', 'id'), coalesce(max(id), 0) + 1, false) FROM collections;
+ALTER TABLE promotions ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('promotions', 'id'), coalesce(max(id), 0) + 1, false) FROM promotions;
+ALTER TABLE orders ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('orders', 'id'), coalesce(max(id), 0) + 1, false) FROM orders;
+ALTER TABLE combos ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('combos', 'id'), coalesce(max(id), 0) + 1, false) FROM combos;
+ALTER TABLE modifier_groups ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('modifier_groups', 'id'), coalesce(max(id), 0) + 1, false) FROM modifier_groups;
+ALTER TABLE modifiers ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('modifiers', 'id'), coalesce(max(id), 0) + 1, false) FROM modifiers;
+ALTER TABLE tables ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('tables', 'id'), coalesce(max(id), 0) + 1, false) FROM tables;
+ALTER TABLE reservations ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('reservations', 'id'), coalesce(max(id), 0) + 1, false) FROM reservations;
+ALTER TABLE webhook_subscriptions ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('webhook_subscriptions', 'id'), coalesce(max(id), 0) + 1, false) FROM webhook_subscriptions;
+
+-- +goose Down
+ALTER TABLE webhook_subscriptions ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE reservations ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE tables ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE modifiers ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE modifier_groups ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE combos ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE orders ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE promotions ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE collections ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE dishes ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE persons ALTER COLUMN id DROP IDENTITY;
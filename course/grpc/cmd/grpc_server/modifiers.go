@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"log"
+)
+
+// loadModifierGroups selects modifier groups with their options for every dish in dishIds.
+func loadModifierGroups(ctx context.Context, q querier, dishIds []int32) (map[int32][]*desc.ModifierGroup, error) {
+	query, args, err := squirrel.Select("g.id", "g.dish_id", "g.name", "g.multiple", "g.min_selections", "g.max_selections", "m.id", "m.name", "m.price_delta").
+		From("modifier_groups g").
+		LeftJoin("modifiers m ON m.group_id = g.id").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"g.dish_id": dishIds}).
+		OrderBy("g.dish_id", "g.id", "m.id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select modifier groups: %v", err)
+		return nil, errors.New("failed to select modifier groups")
+	}
+	defer rows.Close()
+
+	groups := make(map[int32][]*desc.ModifierGroup)
+	var last *desc.ModifierGroup
+	for rows.Next() {
+		g := &desc.ModifierGroup{}
+		var modifierId, priceDelta *int32
+		var modifierName *string
+		err = rows.Scan(&g.Id, &g.DishId, &g.Name, &g.Multiple, &g.MinSelections, &g.MaxSelections, &modifierId, &modifierName, &priceDelta)
+		if err != nil {
+			log.Printf("failed to scan modifier group: %v", err)
+			return nil, errors.New("failed to scan modifier group")
+		}
+		if last == nil || last.GetId() != g.GetId() {
+			last = g
+			groups[g.GetDishId()] = append(groups[g.GetDishId()], g)
+		}
+		if modifierId != nil {
+			last.Options = append(last.Options, &desc.Modifier{
+				Id:   *modifierId,
+				Info: &desc.ModifierInfo{Name: *modifierName, PriceDelta: *priceDelta},
+			})
+		}
+	}
+	return groups, nil
+}
+
+// checkModifiers validates selected modifiers against dish groups and returns the total price delta.
+func checkModifiers(groups []*desc.ModifierGroup, modifierIds []int32) (int64, error) {
+	selected := make(map[int32]bool, len(modifierIds))
+	for _, id := range modifierIds {
+		if selected[id] {
+			return 0, errors.New("modifier is selected twice")
+		}
+		selected[id] = true
+	}
+
+	var delta int64
+	matched := 0
+	for _, g := range groups {
+		count := int32(0)
+		for _, m := range g.GetOptions() {
+			if selected[m.GetId()] {
+				count++
+				delta += int64(m.GetInfo().GetPriceDelta())
+			}
+		}
+		if count < g.GetMinSelections() || count > g.GetMaxSelections() {
+			return 0, errors.New("wrong number of selected modifiers in group " + g.GetName())
+		}
+		matched += int(count)
+	}
+	if matched != len(modifierIds) {
+		return 0, errors.New("modifier does not belong to this dish")
+	}
+	return delta, nil
+}
+
+func (s *server) CreateModifierGroup(ctx context.Context, req *desc.CreateModifierGroupRequest) (*desc.CreateModifierGroupResponse, error) {
+	info := req.GetInfo()
+	if info.GetName() == "" {
+		return nil, errors.New("modifier group name is empty")
+	}
+	if len(info.GetOptions()) == 0 {
+		return nil, errors.New("modifier group has no options")
+	}
+	for _, option := range info.GetOptions() {
+		if option.GetName() == "" {
+			return nil, errors.New("modifier name is empty")
+		}
+	}
+	if info.GetMinSelections() < 0 || info.GetMaxSelections() < 1 || info.GetMaxSelections() < info.GetMinSelections() {
+		return nil, errors.New("invalid modifier selection limits")
+	}
+	if !info.GetMultiple() && info.GetMaxSelections() != 1 {
+		return nil, errors.New("single choice modifier group must allow exactly one selection")
+	}
+	if int(info.GetMinSelections()) > len(info.GetOptions()) {
+		return nil, errors.New("modifier group requires more selections than it has options")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkDishExists(ctx, pool, req.GetDishId()); err != nil {
+		return nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Insert("modifier_groups").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("dish_id", "name", "multiple", "min_selections", "max_selections").
+		Values(req.GetDishId(), info.GetName(), info.GetMultiple(), info.GetMinSelections(), info.GetMaxSelections()).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var id int32
+	if err = tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		log.Printf("failed to insert modifier group: %v", err)
+		return nil, errors.New("failed to insert modifier group")
+	}
+
+	builderInsert := squirrel.Insert("modifiers").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("group_id", "name", "price_delta")
+	for _, option := range info.GetOptions() {
+		builderInsert = builderInsert.Values(id, option.GetName(), option.GetPriceDelta())
+	}
+	query, args, err = builderInsert.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		log.Printf("failed to insert modifiers: %v", err)
+		return nil, errors.New("failed to insert modifiers")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert modifier group")
+	}
+	return &desc.CreateModifierGroupResponse{Id: id}, nil
+}
+
+func (s *server) ListModifierGroups(ctx context.Context, req *desc.ListModifierGroupsRequest) (*desc.ListModifierGroupsResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	groups, err := loadModifierGroups(ctx, pool, []int32{req.GetDishId()})
+	if err != nil {
+		return nil, err
+	}
+	res := groups[req.GetDishId()]
+	if res == nil {
+		res = make([]*desc.ModifierGroup, 0)
+	}
+	return &desc.ListModifierGroupsResponse{Groups: res}, nil
+}
+
+func (s *server) DeleteModifierGroup(ctx context.Context, req *desc.DeleteModifierGroupRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := squirrel.Delete("modifier_groups").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete modifier group: %v", err)
+		return nil, errors.New("failed to delete modifier group")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no modifier group with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"math"
+	"time"
+)
+
+// maxOrderQuantity bounds the quantity of an order line.
+const maxOrderQuantity = 1000
+
+// orderDish is the part of a dish needed to price an order line.
+type orderDish struct {
+	price    int32
+	category string
+}
+
+func (s *server) CreateOrder(ctx context.Context, req *desc.CreateOrderRequest) (*desc.CreateOrderResponse, error) {
+	if len(req.GetLines()) == 0 {
+		return nil, errors.New("order has no lines")
+	}
+	dishIds := make([]int32, 0, len(req.GetLines()))
+	comboIds := make([]int32, 0)
+	for _, line := range req.GetLines() {
+		if line.GetQuantity() <= 0 || line.GetQuantity() > maxOrderQuantity {
+			return nil, status.Errorf(codes.InvalidArgument, "order line quantity must be between 1 and %d", maxOrderQuantity)
+		}
+		if (line.GetDishId() == 0) == (line.GetComboId() == 0) {
+			return nil, errors.New("order line must reference either a dish or a combo")
+		}
+		if line.GetComboId() != 0 {
+			if len(line.GetModifierIds()) > 0 {
+				return nil, errors.New("modifiers can not be applied to a combo")
+			}
+			comboIds = append(comboIds, line.GetComboId())
+		} else {
+			dishIds = append(dishIds, line.GetDishId())
+		}
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = checkExists(ctx, pool, "persons", req.GetPersonId()); err != nil {
+		return nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	dishes, err := loadOrderDishes(ctx, tx, dishIds)
+	if err != nil {
+		return nil, err
+	}
+
+	modifierGroups, err := loadModifierGroups(ctx, tx, dishIds)
+	if err != nil {
+		return nil, err
+	}
+
+	combos := make(map[int32]*desc.Combo)
+	if len(comboIds) > 0 {
+		list, err := selectCombos(ctx, tx, squirrel.Select("id", "name", "description", "price", "available", "created_at", "updated_at").
+			From("combos").
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{"id": comboIds}))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range list {
+			combos[c.GetId()] = c
+		}
+	}
+
+	promos, err := loadPromotions(ctx, tx, req.GetPromoCode())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetPromoCode() != "" {
+		found := false
+		for _, p := range promos {
+			found = found || p.code == req.GetPromoCode()
+		}
+		if !found {
+			return nil, errors.New("promo code is not valid")
+		}
+	}
+
+	order := &desc.Order{
+		PersonId:  req.GetPersonId(),
+		Lines:     make([]*desc.OrderLine, 0, len(req.GetLines())),
+		CreatedAt: timestamppb.New(time.Now()),
+	}
+	fired := make(map[int32]*promotion)
+	firedDiscount := make(map[int32]int32)
+	firedOrder := make([]int32, 0)
+	fire := func(p *promotion, discount int32) {
+		if _, ok := fired[p.id]; !ok {
+			fired[p.id] = p
+			firedOrder = append(firedOrder, p.id)
+		}
+		firedDiscount[p.id] += discount
+	}
+
+	// Amounts are summed in int64 and checked to fit the int32 fields of the order.
+	var subtotal, discount int64
+	for _, info := range req.GetLines() {
+		quantity := int64(info.GetQuantity())
+		if info.GetComboId() != 0 {
+			combo, ok := combos[info.GetComboId()]
+			if !ok {
+				return nil, errors.New("there is no combo with such id in system")
+			}
+			if err = checkComboChoices(combo, info.GetComboChoices()); err != nil {
+				return nil, err
+			}
+			line := &desc.OrderLine{
+				Info:      info,
+				UnitPrice: combo.GetInfo().GetPrice(),
+			}
+			if line.Total, err = orderAmount(int64(line.UnitPrice) * quantity); err != nil {
+				return nil, err
+			}
+			subtotal += int64(line.Total)
+			order.Lines = append(order.Lines, line)
+			continue
+		}
+
+		dish, ok := dishes[info.GetDishId()]
+		if !ok {
+			return nil, errors.New("there is no dish with such id in system")
+		}
+		delta, err := checkModifiers(modifierGroups[info.GetDishId()], info.GetModifierIds())
+		if err != nil {
+			return nil, err
+		}
+		unitPrice, err := modifiedUnitPrice(dish.price, delta)
+		if err != nil {
+			return nil, err
+		}
+		line := &desc.OrderLine{
+			Info:      info,
+			UnitPrice: unitPrice,
+		}
+		lineSubtotal, err := orderAmount(int64(line.UnitPrice) * quantity)
+		if err != nil {
+			return nil, err
+		}
+		if p, d := bestDishPromotion(promos, info.GetDishId(), dish.category, line.UnitPrice); p != nil {
+			if line.Discount, err = orderAmount(int64(d) * quantity); err != nil {
+				return nil, err
+			}
+			line.Promotion = p.applied(line.Discount)
+			fire(p, line.Discount)
+		}
+		line.Total = lineSubtotal - line.Discount
+		subtotal += int64(lineSubtotal)
+		discount += int64(line.Discount)
+		order.Lines = append(order.Lines, line)
+	}
+
+	if order.Subtotal, err = orderAmount(subtotal); err != nil {
+		return nil, err
+	}
+	if order.Discount, err = orderAmount(discount); err != nil {
+		return nil, err
+	}
+	if p, d := bestOrderPromotion(promos, order.Subtotal-order.Discount); p != nil {
+		order.Discount += d
+		fire(p, d)
+	}
+	order.Total = order.Subtotal - order.Discount
+
+	order.Promotions = make([]*desc.AppliedPromotion, 0, len(firedOrder))
+	for _, promotionId := range firedOrder {
+		order.Promotions = append(order.Promotions, fired[promotionId].applied(firedDiscount[promotionId]))
+	}
+
+	if req.GetPromoCode() != "" {
+		found := false
+		for _, p := range fired {
+			found = found || p.code == req.GetPromoCode()
+		}
+		if !found {
+			return nil, errors.New("promo code does not apply to this order")
+		}
+	}
+
+	for _, promotionId := range firedOrder {
+		query, args, err := squirrel.Update("promotions").
+			PlaceholderFormat(squirrel.Dollar).
+			Set("used", squirrel.Expr("used + 1")).
+			Where(squirrel.Eq{"id": promotionId}).
+			Where(squirrel.Or{squirrel.Eq{"usage_limit": 0}, squirrel.Expr("used < usage_limit")}).
+			ToSql()
+		if err != nil {
+			log.Printf("failed to build query: %v", err)
+			return nil, errors.New("failed to build query")
+		}
+		res, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			log.Printf("failed to update promotion usage: %v", err)
+			return nil, errors.New("failed to update promotion usage")
+		}
+		if res.RowsAffected() == 0 {
+			return nil, errors.New("promotion usage limit reached")
+		}
+	}
+
+	if err = insertOrder(ctx, tx, order, req.GetPromoCode()); err != nil {
+		return nil, err
+	}
+
+	event, err := orderCreatedEvent(order)
+	if err != nil {
+		log.Printf("failed to encode event: %v", err)
+		return nil, errors.New("failed to encode event")
+	}
+	if err = writeEvent(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert order")
+	}
+	return &desc.CreateOrderResponse{Order: order}, nil
+}
+
+// orderAmount converts an amount computed in int64 to the int32 of the API, amounts which do not fit are rejected.
+func orderAmount(amount int64) (int32, error) {
+	if amount > math.MaxInt32 || amount < math.MinInt32 {
+		return 0, status.Error(codes.InvalidArgument, "order total is too large")
+	}
+	return int32(amount), nil
+}
+
+// modifiedUnitPrice returns the price of a dish with the price delta of its selected modifiers,
+// discounting modifiers may not take it below zero.
+func modifiedUnitPrice(price int32, delta int64) (int32, error) {
+	unitPrice := int64(price) + delta
+	if unitPrice < 0 {
+		return 0, status.Error(codes.InvalidArgument, "selected modifiers make the dish price negative")
+	}
+	return orderAmount(unitPrice)
+}
+
+// checkComboChoices verifies that combo can be ordered with given dish per slot.
+func checkComboChoices(combo *desc.Combo, choices []int32) error {
+	if !combo.GetInfo().GetAvailable() {
+		return errors.New("combo is not available")
+	}
+	slots := combo.GetInfo().GetSlots()
+	if len(choices) != len(slots) {
+		return errors.New("combo choices must contain one dish per slot")
+	}
+	for i, slot := range slots {
+		found := false
+		for _, dishId := range slot.GetDishIds() {
+			found = found || dishId == choices[i]
+		}
+		if !found {
+			return errors.New("dish is not a choice of combo slot " + slot.GetName())
+		}
+	}
+	return nil
+}
+
+func loadOrderDishes(ctx context.Context, tx pgx.Tx, dishIds []int32) (map[int32]orderDish, error) {
+	query, args, err := squirrel.Select("id", "price", "category").
+		From("dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": dishIds}).
+		Where(notDeleted).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select dishes: %v", err)
+		return nil, errors.New("failed to select dishes")
+	}
+	defer rows.Close()
+
+	dishes := make(map[int32]orderDish)
+	for rows.Next() {
+		var id int32
+		var dish orderDish
+		if err = rows.Scan(&id, &dish.price, &dish.category); err != nil {
+			log.Printf("failed to scan dish: %v", err)
+			return nil, errors.New("failed to scan dish")
+		}
+		dishes[id] = dish
+	}
+	return dishes, nil
+}
+
+func insertOrder(ctx context.Context, tx pgx.Tx, order *desc.Order, promoCode string) error {
+	var code interface{}
+	if promoCode != "" {
+		code = promoCode
+	}
+	query, args, err := squirrel.Insert("orders").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("person_id", "subtotal", "discount", "total", "promo_code", "created_at").
+		Values(order.GetPersonId(), order.GetSubtotal(), order.GetDiscount(), order.GetTotal(), code, order.GetCreatedAt().AsTime()).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+	if err = tx.QueryRow(ctx, query, args...).Scan(&order.Id); err != nil {
+		log.Printf("failed to insert order: %v", err)
+		return errors.New("failed to insert order")
+	}
+
+	builderLines := squirrel.Insert("order_lines").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("order_id", "position", "dish_id", "combo_id", "combo_choices", "modifier_ids", "quantity", "unit_price", "discount", "total", "promotion_id", "promotion_name", "explanation")
+	for i, line := range order.GetLines() {
+		var dishId, comboId, comboChoices, modifierIds interface{}
+		if line.GetInfo().GetComboId() != 0 {
+			comboId = line.GetInfo().GetComboId()
+			comboChoices = line.GetInfo().GetComboChoices()
+		} else {
+			dishId = line.GetInfo().GetDishId()
+			modifierIds = line.GetInfo().GetModifierIds()
+		}
+		var promotionId, promotionName, explanation interface{}
+		if line.GetPromotion() != nil {
+			promotionId = line.GetPromotion().GetPromotionId()
+			promotionName = line.GetPromotion().GetName()
+			explanation = line.GetPromotion().GetExplanation()
+		}
+		builderLines = builderLines.Values(order.GetId(), i, dishId, comboId, comboChoices, modifierIds, line.GetInfo().GetQuantity(), line.GetUnitPrice(), line.GetDiscount(), line.GetTotal(), promotionId, promotionName, explanation)
+	}
+	query, args, err = builderLines.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		log.Printf("failed to insert order lines: %v", err)
+		return errors.New("failed to insert order lines")
+	}
+
+	if len(order.GetPromotions()) == 0 {
+		return nil
+	}
+	builderPromotions := squirrel.Insert("order_promotions").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("order_id", "promotion_id", "name", "discount", "explanation")
+	for _, p := range order.GetPromotions() {
+		builderPromotions = builderPromotions.Values(order.GetId(), p.GetPromotionId(), p.GetName(), p.GetDiscount(), p.GetExplanation())
+	}
+	query, args, err = builderPromotions.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		log.Printf("failed to insert order promotions: %v", err)
+		return errors.New("failed to insert order promotions")
+	}
+	return nil
+}
+
+func (s *server) GetOrder(ctx context.Context, req *desc.GetOrderRequest) (*desc.GetOrderResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := squirrel.Select("id", "person_id", "subtotal", "discount", "total", "created_at").
+		From("orders").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	order := &desc.Order{}
+	var createdAt time.Time
+	err = pool.QueryRow(ctx, query, args...).Scan(&order.Id, &order.PersonId, &order.Subtotal, &order.Discount, &order.Total, &createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("there is no order with such id in system: %v", err)
+		return nil, errors.New("there is no order with such id in system")
+	} else if err != nil {
+		log.Printf("failed to select order: %v", err)
+		return nil, errors.New("failed to select order")
+	}
+	order.CreatedAt = timestamppb.New(createdAt)
+
+	query, args, err = squirrel.Select("dish_id", "combo_id", "combo_choices", "modifier_ids", "quantity", "unit_price", "discount", "total", "promotion_id", "promotion_name", "explanation").
+		From("order_lines").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"order_id": req.GetId()}).
+		OrderBy("position").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select order lines: %v", err)
+		return nil, errors.New("failed to select order lines")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		line := &desc.OrderLine{Info: &desc.OrderLineInfo{}}
+		var dishId, comboId, promotionId *int32
+		var promotionName, explanation *string
+		err = rows.Scan(&dishId, &comboId, &line.Info.ComboChoices, &line.Info.ModifierIds, &line.Info.Quantity, &line.UnitPrice, &line.Discount, &line.Total, &promotionId, &promotionName, &explanation)
+		if err != nil {
+			log.Printf("failed to scan order line: %v", err)
+			return nil, errors.New("failed to scan order line")
+		}
+		if dishId != nil {
+			line.Info.DishId = *dishId
+		}
+		if comboId != nil {
+			line.Info.ComboId = *comboId
+		}
+		if promotionId != nil {
+			line.Promotion = &desc.AppliedPromotion{
+				PromotionId: *promotionId,
+				Name:        *promotionName,
+				Discount:    line.Discount,
+				Explanation: *explanation,
+			}
+		}
+		order.Lines = append(order.Lines, line)
+	}
+	rows.Close()
+
+	query, args, err = squirrel.Select("promotion_id", "name", "discount", "explanation").
+		From("order_promotions").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"order_id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	promoRows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select order promotions: %v", err)
+		return nil, errors.New("failed to select order promotions")
+	}
+	defer promoRows.Close()
+	for promoRows.Next() {
+		p := &desc.AppliedPromotion{}
+		if err = promoRows.Scan(&p.PromotionId, &p.Name, &p.Discount, &p.Explanation); err != nil {
+			log.Printf("failed to scan order promotion: %v", err)
+			return nil, errors.New("failed to scan order promotion")
+		}
+		order.Promotions = append(order.Promotions, p)
+	}
+	return &desc.GetOrderResponse{Order: order}, nil
+}
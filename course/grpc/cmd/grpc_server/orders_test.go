@@ -0,0 +1,46 @@
+package main
+
+import (
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math"
+	"testing"
+)
+
+func TestModifiedUnitPrice(t *testing.T) {
+	groups := []*desc.ModifierGroup{{
+		Name:          "size",
+		Multiple:      true,
+		MaxSelections: 3,
+		Options: []*desc.Modifier{
+			{Id: 1, Info: &desc.ModifierInfo{Name: "small", PriceDelta: -300}},
+			{Id: 2, Info: &desc.ModifierInfo{Name: "no sauce", PriceDelta: -50}},
+			{Id: 3, Info: &desc.ModifierInfo{Name: "truffle", PriceDelta: math.MaxInt32}},
+		},
+	}}
+	tests := []struct {
+		name      string
+		price     int32
+		modifiers []int32
+		want      int32
+		code      codes.Code
+	}{
+		{"no modifiers", 250, nil, 250, codes.OK},
+		{"discount", 400, []int32{1}, 100, codes.OK},
+		{"down to zero", 350, []int32{1, 2}, 0, codes.OK},
+		{"below zero", 250, []int32{1}, 0, codes.InvalidArgument},
+		{"free dish", 0, []int32{2}, 0, codes.InvalidArgument},
+		{"too large", 1, []int32{3}, 0, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		delta, err := checkModifiers(groups, tt.modifiers)
+		if err != nil {
+			t.Fatalf("%s: checkModifiers: %v", tt.name, err)
+		}
+		got, err := modifiedUnitPrice(tt.price, delta)
+		if status.Code(err) != tt.code || got != tt.want {
+			t.Errorf("%s: modifiedUnitPrice = %d, %v, want %d, %v", tt.name, got, err, tt.want, tt.code)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The relay publishes events from the outbox to the configured sinks at least once and in order
+// per aggregate: an event is marked as published only after every sink accepted it, and an event
+// is not published while an earlier event of its aggregate waits for a retry. Failed events are
+// retried with exponential backoff and dead-lettered after outboxMaxAttempts.
+const (
+	outboxSinksEnv         = "OUTBOX_SINKS"
+	outboxWebhookURLEnv    = "OUTBOX_WEBHOOK_URL"
+	outboxPollIntervalEnv  = "OUTBOX_POLL_INTERVAL"
+	defaultOutboxSinks     = "bus"
+	defaultOutboxInterval  = time.Second
+	outboxBatchSize        = 100
+	outboxPublishTimeout   = 10 * time.Second
+	outboxLease            = time.Minute
+	outboxMaxAttempts      = 12
+	outboxBaseDelay        = 5 * time.Second
+	outboxMaxDelay         = time.Hour
+	outboxMaxErrorLength   = 1000
+	outboxWebhookUserAgent = "productionSite-outbox"
+	// outboxClaimLockID serialises relays of several servers claiming events.
+	outboxClaimLockID = 7718
+)
+
+// eventSink receives the published domain events. e is the database of the relay, sinks which
+// write to it use it instead of connecting on their own.
+type eventSink interface {
+	Name() string
+	Publish(ctx context.Context, e execer, event domainEvent) error
+}
+
+// eventBus is the in-process sink, handlers run synchronously in the relay.
+type eventBus struct {
+	mu       sync.RWMutex
+	handlers []func(ctx context.Context, e execer, event domainEvent) error
+}
+
+var localBus = &eventBus{}
+
+func (b *eventBus) Name() string { return "bus" }
+
+func (b *eventBus) Subscribe(handler func(ctx context.Context, e execer, event domainEvent) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *eventBus) Publish(ctx context.Context, e execer, event domainEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		if err := handler(ctx, e, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookSink posts every event as JSON to a single URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Name() string { return "webhook" }
+
+func (s *webhookSink) Publish(ctx context.Context, e execer, event domainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", outboxWebhookUserAgent)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+	return nil
+}
+
+// outboxSinks builds the sinks listed in OUTBOX_SINKS.
+func outboxSinks() ([]eventSink, error) {
+	names := os.Getenv(outboxSinksEnv)
+	if names == "" {
+		names = defaultOutboxSinks
+	}
+	var sinks []eventSink
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "bus":
+			sinks = append(sinks, localBus)
+		case "webhook":
+			url := os.Getenv(outboxWebhookURLEnv)
+			if url == "" {
+				return nil, fmt.Errorf("%s is required by the webhook sink", outboxWebhookURLEnv)
+			}
+			sinks = append(sinks, &webhookSink{url: url, client: &http.Client{Timeout: outboxPublishTimeout}})
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// relayOutbox publishes a batch of due events. Events are leased in a short transaction, published
+// without holding it, and their results are recorded in another one.
+func relayOutbox(ctx context.Context, sinks []eventSink) error {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	events, err := claimOutboxEvents(ctx, pool)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, outboxLease)
+	results := publishOutboxEvents(publishCtx, pool, sinks, events)
+	cancel()
+
+	return recordOutboxResults(ctx, pool, results)
+}
+
+// claimOutboxEvents leases up to outboxBatchSize due events in id order. An event is due unless
+// an earlier pending event of its aggregate is leased or waits for a retry.
+func claimOutboxEvents(ctx context.Context, pool *pgxpool.Pool) ([]domainEvent, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", outboxClaimLockID); err != nil {
+		log.Printf("failed to lock outbox: %v", err)
+		return nil, errors.New("failed to lock outbox")
+	}
+
+	query, args, err := outboxClaimUpdate()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to claim events: %v", err)
+		return nil, errors.New("failed to claim events")
+	}
+	var events []domainEvent
+	for rows.Next() {
+		var event domainEvent
+		if err = rows.Scan(&event.Id, &event.Type, &event.Aggregate, &event.AggregateId, &event.OccurredAt, &event.Payload, &event.attempts); err != nil {
+			rows.Close()
+			log.Printf("failed to scan event: %v", err)
+			return nil, errors.New("failed to scan event")
+		}
+		event.OccurredAt = event.OccurredAt.UTC()
+		events = append(events, event)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read events: %v", err)
+		return nil, errors.New("failed to read events")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to claim events")
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Id < events[j].Id })
+	return events, nil
+}
+
+// outboxClaimUpdate builds the query leasing due events, see claimOutboxEvents.
+func outboxClaimUpdate() (string, []interface{}, error) {
+	due := squirrel.Select("o.id").
+		From("outbox o").
+		Where("o.published_at IS NULL AND o.dead_at IS NULL").
+		Where("o.next_attempt_at <= now()").
+		Where("(o.locked_until IS NULL OR o.locked_until < now())").
+		Where(`NOT EXISTS (SELECT 1 FROM outbox p
+			WHERE p.aggregate = o.aggregate AND p.aggregate_id = o.aggregate_id AND p.id < o.id
+			AND p.published_at IS NULL AND p.dead_at IS NULL
+			AND (p.next_attempt_at > now() OR p.locked_until >= now()))`).
+		OrderBy("o.id").
+		Limit(outboxBatchSize)
+	dueSql, _, err := due.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return squirrel.Update("outbox").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("locked_until", squirrel.Expr("now() + make_interval(secs => ?)", outboxLease.Seconds())).
+		Where("id IN (" + dueSql + ")").
+		Suffix("RETURNING id, event_type, aggregate, aggregate_id, occurred_at, payload, attempts").
+		ToSql()
+}
+
+// outboxResult is the outcome of publishing a claimed event, an event which was not attempted
+// because an earlier event of its aggregate failed or the lease ran out is skipped.
+type outboxResult struct {
+	event   domainEvent
+	skipped bool
+	err     error
+}
+
+// publishOutboxEvents publishes events in order. After a failure the later events of the same
+// aggregate are skipped, so they are not delivered before the failed one.
+func publishOutboxEvents(ctx context.Context, e execer, sinks []eventSink, events []domainEvent) []outboxResult {
+	type aggregateKey struct {
+		aggregate string
+		id        int32
+	}
+	failed := make(map[aggregateKey]bool)
+	results := make([]outboxResult, 0, len(events))
+	for _, event := range events {
+		key := aggregateKey{event.Aggregate, event.AggregateId}
+		if failed[key] || ctx.Err() != nil {
+			results = append(results, outboxResult{event: event, skipped: true})
+			continue
+		}
+		err := publishEvent(ctx, e, sinks, event)
+		if err != nil {
+			log.Printf("failed to publish event %d: %v", event.Id, err)
+			failed[key] = true
+		}
+		results = append(results, outboxResult{event: event, err: err})
+	}
+	return results
+}
+
+func publishEvent(ctx context.Context, e execer, sinks []eventSink, event domainEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+	defer cancel()
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, e, event); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// outboxResultUpdate releases the lease of an event and records its result.
+func outboxResultUpdate(result outboxResult, now time.Time) squirrel.UpdateBuilder {
+	builderUpdate := squirrel.Update("outbox").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("locked_until", nil).
+		Where(squirrel.Eq{"id": result.event.Id})
+	if result.skipped {
+		return builderUpdate
+	}
+
+	attempts := result.event.attempts + 1
+	builderUpdate = builderUpdate.Set("attempts", attempts)
+	switch {
+	case result.err == nil:
+		builderUpdate = builderUpdate.
+			Set("published_at", sqlNow).
+			Set("last_error", nil)
+	case attempts >= outboxMaxAttempts:
+		log.Printf("event %d failed %d times, moved to the dead letters: %v", result.event.Id, attempts, result.err)
+		builderUpdate = builderUpdate.
+			Set("dead_at", sqlNow).
+			Set("last_error", truncateError(result.err, outboxMaxErrorLength))
+	default:
+		builderUpdate = builderUpdate.
+			Set("next_attempt_at", now.Add(retryDelay(attempts, outboxBaseDelay, outboxMaxDelay)).UTC()).
+			Set("last_error", truncateError(result.err, outboxMaxErrorLength))
+	}
+	return builderUpdate
+}
+
+func recordOutboxResults(ctx context.Context, pool *pgxpool.Pool, results []outboxResult) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	for _, result := range results {
+		query, args, err := outboxResultUpdate(result, now).ToSql()
+		if err != nil {
+			log.Printf("failed to build query: %v", err)
+			return errors.New("failed to build query")
+		}
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			log.Printf("failed to update event: %v", err)
+			return errors.New("failed to update event")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return errors.New("failed to update events")
+	}
+	return nil
+}
+
+// retryDelay doubles base after every failed attempt up to max and adds up to 20% of jitter.
+func retryDelay(attempts int32, base, max time.Duration) time.Duration {
+	delay := max
+	if attempts < 20 {
+		delay = base << uint(attempts-1)
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay + time.Duration(mathrand.Int63n(int64(delay)/5+1))
+}
+
+func startOutboxRelay() {
+	sinks, err := outboxSinks()
+	if err != nil {
+		log.Fatalf("failed to configure outbox: %v", err)
+	}
+	interval := durationFromEnv(outboxPollIntervalEnv, defaultOutboxInterval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			relayOutbox(context.Background(), sinks)
+		}
+	}()
+}
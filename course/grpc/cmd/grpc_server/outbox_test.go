@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingSink is a local stand-in for a sink, it fails the events listed in fail.
+type recordingSink struct {
+	fail      map[int64]bool
+	published []int64
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Publish(ctx context.Context, e execer, event domainEvent) error {
+	if s.fail[event.Id] {
+		return errors.New("unavailable")
+	}
+	s.published = append(s.published, event.Id)
+	return nil
+}
+
+func TestPublishOutboxEventsKeepsAggregateOrder(t *testing.T) {
+	events := []domainEvent{
+		{Id: 1, Aggregate: "dish", AggregateId: 1},
+		{Id: 2, Aggregate: "dish", AggregateId: 2},
+		{Id: 3, Aggregate: "dish", AggregateId: 1},
+		{Id: 4, Aggregate: "person", AggregateId: 1},
+	}
+	sink := &recordingSink{fail: map[int64]bool{1: true}}
+
+	results := publishOutboxEvents(context.Background(), nil, []eventSink{sink}, events)
+
+	if want := []int64{2, 4}; !reflect.DeepEqual(sink.published, want) {
+		t.Errorf("published %v, want %v", sink.published, want)
+	}
+	if results[0].err == nil || results[0].skipped {
+		t.Errorf("event 1: %+v, want a failure", results[0])
+	}
+	if !results[2].skipped {
+		t.Errorf("event 3 of the failed aggregate was not skipped: %+v", results[2])
+	}
+	if results[1].err != nil || results[1].skipped || results[3].err != nil || results[3].skipped {
+		t.Errorf("events of other aggregates were not published: %+v", results)
+	}
+}
+
+func TestPublishOutboxEventsStopsAfterLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sink := &recordingSink{}
+	results := publishOutboxEvents(ctx, nil, []eventSink{sink}, []domainEvent{{Id: 1, Aggregate: "dish", AggregateId: 1}})
+	if len(sink.published) != 0 || !results[0].skipped {
+		t.Errorf("event published after the lease: %+v", results)
+	}
+}
+
+func TestOutboxResultUpdate(t *testing.T) {
+	now := time.Now()
+	event := domainEvent{Id: 7, attempts: 2}
+	tests := []struct {
+		name   string
+		result outboxResult
+		want   []string
+		absent []string
+	}{
+		{"published", outboxResult{event: event}, []string{"published_at", "attempts"}, []string{"dead_at", "next_attempt_at"}},
+		{"retried", outboxResult{event: event, err: errors.New("down")}, []string{"next_attempt_at", "last_error"}, []string{"dead_at", "published_at"}},
+		{"dead", outboxResult{event: domainEvent{Id: 7, attempts: outboxMaxAttempts - 1}, err: errors.New("down")}, []string{"dead_at", "last_error"}, []string{"next_attempt_at", "published_at"}},
+		{"skipped", outboxResult{event: event, skipped: true}, []string{"locked_until"}, []string{"attempts", "last_error", "published_at"}},
+	}
+	for _, tt := range tests {
+		query, _, err := outboxResultUpdate(tt.result, now).ToSql()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, column := range append(tt.want, "locked_until") {
+			if !strings.Contains(query, column+" = ") {
+				t.Errorf("%s: %q does not set %s", tt.name, query, column)
+			}
+		}
+		for _, column := range tt.absent {
+			if strings.Contains(query, column+" = ") {
+				t.Errorf("%s: %q sets %s", tt.name, query, column)
+			}
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base, max := time.Second, time.Minute
+	previous := time.Duration(0)
+	for attempts := int32(1); attempts <= 30; attempts++ {
+		delay := retryDelay(attempts, base, max)
+		floor := base << uint(attempts-1)
+		if attempts >= 20 || floor > max {
+			floor = max
+		}
+		if delay < floor || delay > floor+floor/5 {
+			t.Errorf("attempt %d: delay %v, want %v plus at most 20%%", attempts, delay, floor)
+		}
+		if floor < previous {
+			t.Errorf("attempt %d: delay decreased", attempts)
+		}
+		previous = floor
+	}
+}
+
+func TestOutboxClaimUpdate(t *testing.T) {
+	query, args, err := outboxClaimUpdate()
+	if err != nil {
+		t.Fatalf("outboxClaimUpdate: %v", err)
+	}
+	for _, part := range []string{
+		"o.published_at IS NULL AND o.dead_at IS NULL",
+		"o.next_attempt_at <= now()",
+		"(o.locked_until IS NULL OR o.locked_until < now())",
+		"p.id < o.id",
+		"ORDER BY o.id LIMIT 100",
+		"SET locked_until = now() + make_interval(secs => $1)",
+	} {
+		if !strings.Contains(query, part) {
+			t.Errorf("claim query does not contain %q: %s", part, query)
+		}
+	}
+	if outboxBatchSize != 100 {
+		t.Errorf("batch size %d, update the expected LIMIT", outboxBatchSize)
+	}
+	if len(args) != 1 || args[0] != outboxLease.Seconds() {
+		t.Errorf("claim args %v, want the lease of %v", args, outboxLease)
+	}
+}
+
+func TestOutboxDeadLetters(t *testing.T) {
+	now := time.Now()
+	for attempts := int32(0); attempts < outboxMaxAttempts; attempts++ {
+		result := outboxResult{event: domainEvent{Id: 7, attempts: attempts}, err: errors.New("down")}
+		query, args, err := outboxResultUpdate(result, now).ToSql()
+		if err != nil {
+			t.Fatalf("attempt %d: %v", attempts+1, err)
+		}
+		dead := attempts+1 == outboxMaxAttempts
+		if strings.Contains(query, "dead_at = ") != dead || strings.Contains(query, "next_attempt_at = ") == dead {
+			t.Errorf("attempt %d of %d: %s", attempts+1, outboxMaxAttempts, query)
+		}
+		if args[1] != attempts+1 {
+			t.Errorf("attempt %d: attempts set to %v", attempts+1, args[1])
+		}
+	}
+
+	long := errors.New(strings.Repeat("x", 2*outboxMaxErrorLength))
+	_, args, err := outboxResultUpdate(outboxResult{event: domainEvent{Id: 7}, err: long}, now).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range args {
+		if s, ok := arg.(string); ok && len(s) > outboxMaxErrorLength {
+			t.Errorf("last_error of %d bytes, want at most %d", len(s), outboxMaxErrorLength)
+		}
+	}
+}
+
+// cancellingSink cancels the lease after publishing its first event.
+type cancellingSink struct {
+	recordingSink
+	cancel context.CancelFunc
+}
+
+func (s *cancellingSink) Publish(ctx context.Context, e execer, event domainEvent) error {
+	err := s.recordingSink.Publish(ctx, e, event)
+	s.cancel()
+	return err
+}
+
+func TestPublishOutboxEventsLeaseAndSinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := &cancellingSink{cancel: cancel}
+	events := []domainEvent{
+		{Id: 1, Aggregate: "dish", AggregateId: 1},
+		{Id: 2, Aggregate: "dish", AggregateId: 2},
+	}
+	results := publishOutboxEvents(ctx, nil, []eventSink{sink}, events)
+	if !reflect.DeepEqual(sink.published, []int64{1}) || results[0].err != nil || !results[1].skipped {
+		t.Errorf("lease ran out after the first event: published %v, results %+v", sink.published, results)
+	}
+
+	// The first sink received the event, it gets it again on the retry required by the second one.
+	first := &recordingSink{}
+	second := &recordingSink{fail: map[int64]bool{1: true}}
+	results = publishOutboxEvents(context.Background(), nil, []eventSink{first, second}, events)
+	if !reflect.DeepEqual(first.published, []int64{1, 2}) || !reflect.DeepEqual(second.published, []int64{2}) {
+		t.Errorf("published %v and %v, want [1 2] and [2]", first.published, second.published)
+	}
+	if results[0].err == nil || !strings.HasPrefix(results[0].err.Error(), "recording: ") || results[1].err != nil {
+		t.Errorf("results %+v, want the first event failed by the second sink", results)
+	}
+}
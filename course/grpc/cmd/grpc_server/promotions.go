@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"log"
+	"time"
+)
+
+var discountKinds = map[desc.DiscountKind]string{
+	desc.DiscountKind_DISCOUNT_KIND_PERCENT: "percent",
+	desc.DiscountKind_DISCOUNT_KIND_FIXED:   "fixed",
+}
+
+var promotionTargets = map[desc.PromotionTarget]string{
+	desc.PromotionTarget_PROMOTION_TARGET_DISH:     "dish",
+	desc.PromotionTarget_PROMOTION_TARGET_CATEGORY: "category",
+	desc.PromotionTarget_PROMOTION_TARGET_ORDER:    "order",
+}
+
+// promotion is a promotion row together with its target dishes.
+type promotion struct {
+	id         int32
+	name       string
+	code       string
+	kind       string
+	value      int32
+	target     string
+	dishIds    map[int32]bool
+	category   string
+	validFrom  *time.Time
+	validTo    *time.Time
+	usageLimit int32
+	used       int32
+	createdAt  time.Time
+}
+
+// activeAt reports whether promotion is inside its validity period and has usages left.
+func (p *promotion) activeAt(now time.Time) bool {
+	if p.validFrom != nil && now.Before(*p.validFrom) {
+		return false
+	}
+	if p.validTo != nil && !now.Before(*p.validTo) {
+		return false
+	}
+	return p.usageLimit == 0 || p.used < p.usageLimit
+}
+
+// matchesDish reports whether promotion targets dish with given id and category.
+func (p *promotion) matchesDish(dishId int32, category string) bool {
+	switch p.target {
+	case "dish":
+		return p.dishIds[dishId]
+	case "category":
+		return category != "" && p.category == category
+	}
+	return false
+}
+
+// discount returns the discount of promotion for given price, never more than price.
+func (p *promotion) discount(price int32) int32 {
+	var d int32
+	switch p.kind {
+	case "percent":
+		d = int32(int64(price) * int64(p.value) / 100)
+	case "fixed":
+		d = p.value
+	}
+	if d > price {
+		d = price
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// explain builds a human readable description of why promotion fired.
+func (p *promotion) explain(discount int32) string {
+	var what string
+	switch p.kind {
+	case "percent":
+		what = fmt.Sprintf("%d%% off", p.value)
+	default:
+		what = fmt.Sprintf("%d off", p.value)
+	}
+	var where string
+	switch p.target {
+	case "dish":
+		where = "this dish"
+	case "category":
+		where = fmt.Sprintf("category %q", p.category)
+	default:
+		where = "the whole order"
+	}
+	res := fmt.Sprintf("%s: %s %s, saved %d", p.name, what, where, discount)
+	if p.code != "" {
+		res += fmt.Sprintf(" (promo code %s)", p.code)
+	}
+	return res
+}
+
+func (p *promotion) applied(discount int32) *desc.AppliedPromotion {
+	return &desc.AppliedPromotion{
+		PromotionId: p.id,
+		Name:        p.name,
+		Discount:    discount,
+		Explanation: p.explain(discount),
+	}
+}
+
+func (p *promotion) toProto() *desc.Promotion {
+	info := &desc.PromotionInfo{
+		Name:       p.name,
+		Code:       p.code,
+		Value:      p.value,
+		DishIds:    make([]int32, 0, len(p.dishIds)),
+		Category:   p.category,
+		UsageLimit: p.usageLimit,
+	}
+	for k, v := range discountKinds {
+		if v == p.kind {
+			info.Kind = k
+		}
+	}
+	for k, v := range promotionTargets {
+		if v == p.target {
+			info.Target = k
+		}
+	}
+	for dishId := range p.dishIds {
+		info.DishIds = append(info.DishIds, dishId)
+	}
+	if p.validFrom != nil {
+		info.ValidFrom = timestamppb.New(*p.validFrom)
+	}
+	if p.validTo != nil {
+		info.ValidTo = timestamppb.New(*p.validTo)
+	}
+	return &desc.Promotion{
+		Id:        p.id,
+		Info:      info,
+		Used:      p.used,
+		CreatedAt: timestamppb.New(p.createdAt),
+	}
+}
+
+// bestDishPromotion picks the promotion giving the biggest discount on a dish.
+func bestDishPromotion(promos []*promotion, dishId int32, category string, price int32) (*promotion, int32) {
+	var best *promotion
+	var bestDiscount int32
+	for _, p := range promos {
+		if !p.matchesDish(dishId, category) {
+			continue
+		}
+		if d := p.discount(price); d > bestDiscount {
+			best, bestDiscount = p, d
+		}
+	}
+	return best, bestDiscount
+}
+
+// bestOrderPromotion picks the order-wide promotion giving the biggest discount on subtotal.
+func bestOrderPromotion(promos []*promotion, subtotal int32) (*promotion, int32) {
+	var best *promotion
+	var bestDiscount int32
+	for _, p := range promos {
+		if p.target != "order" {
+			continue
+		}
+		if d := p.discount(subtotal); d > bestDiscount {
+			best, bestDiscount = p, d
+		}
+	}
+	return best, bestDiscount
+}
+
+// applyDishPromotions fills discounted price of dishes using automatic promotions.
+func applyDishPromotions(dishes []*desc.Dish, promos []*promotion) {
+	for _, dish := range dishes {
+		p, d := bestDishPromotion(promos, dish.GetId(), dish.GetInfo().GetCategory(), dish.GetInfo().GetPrice())
+		if p == nil {
+			continue
+		}
+		dish.DiscountedPrice = wrapperspb.Int32(dish.GetInfo().GetPrice() - d)
+		dish.Promotion = p.applied(d)
+	}
+}
+
+// querier is implemented by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// loadPromotions selects promotions which are active now. Promotions with a promo code
+// are included only when their code equals code.
+func loadPromotions(ctx context.Context, q querier, code string) ([]*promotion, error) {
+	builderSelect := squirrel.Select("id", "name", "code", "kind", "value", "target", "category", "valid_from", "valid_to", "usage_limit", "used", "created_at").
+		From("promotions").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("id")
+	if code != "" {
+		builderSelect = builderSelect.Where(squirrel.Or{squirrel.Eq{"code": nil}, squirrel.Eq{"code": code}})
+	} else {
+		builderSelect = builderSelect.Where(squirrel.Eq{"code": nil})
+	}
+
+	promos, err := selectPromotions(ctx, q, builderSelect)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]*promotion, 0, len(promos))
+	for _, p := range promos {
+		if p.activeAt(now) {
+			active = append(active, p)
+		}
+	}
+	return active, nil
+}
+
+func selectPromotions(ctx context.Context, q querier, builderSelect squirrel.SelectBuilder) ([]*promotion, error) {
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select promotions: %v", err)
+		return nil, errors.New("failed to select promotions")
+	}
+	defer rows.Close()
+
+	promos := make([]*promotion, 0)
+	byId := make(map[int32]*promotion)
+	for rows.Next() {
+		p := &promotion{dishIds: make(map[int32]bool)}
+		var code, category *string
+		err = rows.Scan(&p.id, &p.name, &code, &p.kind, &p.value, &p.target, &category, &p.validFrom, &p.validTo, &p.usageLimit, &p.used, &p.createdAt)
+		if err != nil {
+			log.Printf("failed to scan promotion: %v", err)
+			return nil, errors.New("failed to scan promotion")
+		}
+		if code != nil {
+			p.code = *code
+		}
+		if category != nil {
+			p.category = *category
+		}
+		promos = append(promos, p)
+		byId[p.id] = p
+	}
+	rows.Close()
+	if len(promos) == 0 {
+		return promos, nil
+	}
+
+	ids := make([]int32, 0, len(promos))
+	for _, p := range promos {
+		ids = append(ids, p.id)
+	}
+	query, args, err = squirrel.Select("promotion_id", "dish_id").
+		From("promotion_dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"promotion_id": ids}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	dishRows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select promotion dishes: %v", err)
+		return nil, errors.New("failed to select promotion dishes")
+	}
+	defer dishRows.Close()
+	for dishRows.Next() {
+		var promotionId, dishId int32
+		if err = dishRows.Scan(&promotionId, &dishId); err != nil {
+			log.Printf("failed to scan promotion dish: %v", err)
+			return nil, errors.New("failed to scan promotion dish")
+		}
+		byId[promotionId].dishIds[dishId] = true
+	}
+	return promos, nil
+}
+
+func (s *server) CreatePromotion(ctx context.Context, req *desc.CreatePromotionRequest) (*desc.CreatePromotionResponse, error) {
+	info := req.GetInfo()
+	kind, ok := discountKinds[info.GetKind()]
+	if !ok {
+		return nil, errors.New("unknown discount kind")
+	}
+	target, ok := promotionTargets[info.GetTarget()]
+	if !ok {
+		return nil, errors.New("unknown promotion target")
+	}
+	if info.GetName() == "" {
+		return nil, errors.New("promotion name is empty")
+	}
+	if info.GetValue() <= 0 || (kind == "percent" && info.GetValue() > 100) {
+		return nil, errors.New("invalid discount value")
+	}
+	if target == "dish" && len(info.GetDishIds()) == 0 {
+		return nil, errors.New("dish promotion without dishes")
+	}
+	if target == "category" && info.GetCategory() == "" {
+		return nil, errors.New("category promotion without category")
+	}
+	if info.GetValidFrom() != nil && info.GetValidTo() != nil && !info.GetValidFrom().AsTime().Before(info.GetValidTo().AsTime()) {
+		return nil, errors.New("promotion ends before it starts")
+	}
+	if info.GetUsageLimit() < 0 {
+		return nil, errors.New("invalid usage limit")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	for _, dishId := range info.GetDishIds() {
+		if err = checkDishExists(ctx, pool, dishId); err != nil {
+			return nil, err
+		}
+	}
+
+	var code, category, validFrom, validTo interface{}
+	if info.GetCode() != "" {
+		code = info.GetCode()
+	}
+	if info.GetCategory() != "" {
+		category = info.GetCategory()
+	}
+	if info.GetValidFrom() != nil {
+		validFrom = info.GetValidFrom().AsTime()
+	}
+	if info.GetValidTo() != nil {
+		validTo = info.GetValidTo().AsTime()
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Insert("promotions").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("name", "code", "kind", "value", "target", "category", "valid_from", "valid_to", "usage_limit", "used", "created_at").
+		Values(info.GetName(), code, kind, info.GetValue(), target, category, validFrom, validTo, info.GetUsageLimit(), 0, sqlNow).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var id int32
+	if err = tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		log.Printf("failed to insert promotion: %v", err)
+		return nil, errors.New("failed to insert promotion")
+	}
+
+	if len(info.GetDishIds()) > 0 {
+		builderInsert := squirrel.Insert("promotion_dishes").
+			PlaceholderFormat(squirrel.Dollar).
+			Columns("promotion_id", "dish_id").
+			Suffix("ON CONFLICT DO NOTHING")
+		for _, dishId := range info.GetDishIds() {
+			builderInsert = builderInsert.Values(id, dishId)
+		}
+		query, args, err = builderInsert.ToSql()
+		if err != nil {
+			log.Printf("failed to build query: %v", err)
+			return nil, errors.New("failed to build query")
+		}
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			log.Printf("failed to insert promotion dishes: %v", err)
+			return nil, errors.New("failed to insert promotion dishes")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert promotion")
+	}
+	return &desc.CreatePromotionResponse{Id: id}, nil
+}
+
+func (s *server) ListPromotions(ctx context.Context, req *desc.ListPromotionsRequest) (*desc.ListPromotionsResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	builderSelect := squirrel.Select("id", "name", "code", "kind", "value", "target", "category", "valid_from", "valid_to", "usage_limit", "used", "created_at").
+		From("promotions").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("id")
+
+	promos, err := selectPromotions(ctx, pool, builderSelect)
+	if err != nil {
+		return nil, err
+	}
+	manager, err := isManager(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	return &desc.ListPromotionsResponse{Promotions: listedPromotions(promos, manager)}, nil
+}
+
+// listedPromotions converts promotions for ListPromotions. Promo codes are handed out to customers,
+// only managers see them.
+func listedPromotions(promos []*promotion, manager bool) []*desc.Promotion {
+	res := make([]*desc.Promotion, 0, len(promos))
+	for _, p := range promos {
+		promo := p.toProto()
+		if !manager {
+			promo.Info.Code = ""
+		}
+		res = append(res, promo)
+	}
+	return res
+}
+
+func (s *server) DeletePromotion(ctx context.Context, req *desc.DeletePromotionRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	query, args, err := squirrel.Delete("promotions").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete promotion: %v", err)
+		return nil, errors.New("failed to delete promotion")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no promotion with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPromotionDiscount(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  string
+		value int32
+		price int32
+		want  int32
+	}{
+		{"percent", "percent", 10, 250, 25},
+		{"percent rounds down", "percent", 15, 99, 14},
+		{"percent of zero", "percent", 50, 0, 0},
+		{"percent of a large price", "percent", 50, math.MaxInt32, math.MaxInt32 / 2},
+		{"more than a hundred percent", "percent", 150, 200, 200},
+		{"fixed", "fixed", 50, 300, 50},
+		{"fixed above the price", "fixed", 500, 300, 300},
+		{"negative value", "fixed", -10, 300, 0},
+		{"unknown kind", "gift", 10, 300, 0},
+	}
+	for _, tt := range tests {
+		p := &promotion{kind: tt.kind, value: tt.value}
+		if got := p.discount(tt.price); got != tt.want {
+			t.Errorf("%s: discount(%d) = %d, want %d", tt.name, tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionActiveAt(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := []struct {
+		name string
+		p    promotion
+		want bool
+	}{
+		{"unbounded", promotion{}, true},
+		{"started", promotion{validFrom: &before, validTo: &after}, true},
+		{"starts at now", promotion{validFrom: &now}, true},
+		{"not started", promotion{validFrom: &after}, false},
+		{"ends at now", promotion{validTo: &now}, false},
+		{"ended", promotion{validTo: &before}, false},
+		{"usages left", promotion{usageLimit: 3, used: 2}, true},
+		{"used up", promotion{usageLimit: 3, used: 3}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.p.activeAt(now); got != tt.want {
+			t.Errorf("%s: activeAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBestPromotion(t *testing.T) {
+	soup := &promotion{id: 1, kind: "percent", value: 10, target: "dish", dishIds: map[int32]bool{7: true}}
+	soups := &promotion{id: 2, kind: "fixed", value: 40, target: "category", category: "soups"}
+	order := &promotion{id: 3, kind: "percent", value: 5, target: "order"}
+	bigOrder := &promotion{id: 4, kind: "fixed", value: 100, target: "order"}
+	promos := []*promotion{soup, soups, order, bigOrder}
+
+	tests := []struct {
+		name     string
+		dishId   int32
+		category string
+		price    int32
+		want     *promotion
+		discount int32
+	}{
+		{"percent wins", 7, "soups", 500, soup, 50},
+		{"fixed wins", 7, "soups", 300, soups, 40},
+		{"category only", 8, "soups", 300, soups, 40},
+		{"dish only", 7, "", 300, soup, 30},
+		{"no match", 8, "salads", 300, nil, 0},
+		{"free dish", 7, "soups", 0, nil, 0},
+	}
+	for _, tt := range tests {
+		p, d := bestDishPromotion(promos, tt.dishId, tt.category, tt.price)
+		if p != tt.want || d != tt.discount {
+			t.Errorf("%s: bestDishPromotion = %v, %d, want %v, %d", tt.name, p, d, tt.want, tt.discount)
+		}
+	}
+
+	if p, d := bestOrderPromotion(promos, 1000); p != bigOrder || d != 100 {
+		t.Errorf("bestOrderPromotion(1000) = %v, %d, want the fixed promotion", p, d)
+	}
+	if p, d := bestOrderPromotion(promos, 4000); p != order || d != 200 {
+		t.Errorf("bestOrderPromotion(4000) = %v, %d, want the percent promotion", p, d)
+	}
+
+	dishes := []*desc.Dish{
+		{Id: 7, Info: &desc.DishInfo{Price: 500, Category: "soups"}},
+		{Id: 9, Info: &desc.DishInfo{Price: 300, Category: "salads"}},
+	}
+	applyDishPromotions(dishes, promos)
+	if got := dishes[0].GetDiscountedPrice(); got.GetValue() != 450 || dishes[0].GetPromotion().GetPromotionId() != 1 {
+		t.Errorf("discounted soup: %v, %v", got, dishes[0].GetPromotion())
+	}
+	if dishes[1].GetDiscountedPrice() != nil || dishes[1].GetPromotion() != nil {
+		t.Errorf("salad without promotions is discounted: %v", dishes[1])
+	}
+}
+
+func TestListedPromotionCodes(t *testing.T) {
+	promos := []*promotion{
+		{id: 1, name: "Spring", code: "SPRING", kind: "percent", target: "order"},
+		{id: 2, name: "Lunch", kind: "fixed", target: "order"},
+	}
+	for _, manager := range []bool{false, true} {
+		listed := listedPromotions(promos, manager)
+		if len(listed) != 2 {
+			t.Fatalf("manager %v: %d promotions, want 2", manager, len(listed))
+		}
+		want := ""
+		if manager {
+			want = "SPRING"
+		}
+		if code := listed[0].GetInfo().GetCode(); code != want {
+			t.Errorf("manager %v: code %q, want %q", manager, code, want)
+		}
+		if promos[0].code != "SPRING" {
+			t.Errorf("manager %v: listing changed the promotion code to %q", manager, promos[0].code)
+		}
+	}
+}
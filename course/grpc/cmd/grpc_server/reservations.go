@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	reservationDesc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/reservation_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"time"
+)
+
+type reservationServer struct {
+	reservationDesc.UnimplementedReservationV1Server
+}
+
+// overlaps is the condition of an active reservation intersecting [startsAt, endsAt).
+func overlaps(startsAt, endsAt time.Time) squirrel.Sqlizer {
+	return squirrel.And{
+		squirrel.Eq{"r.cancelled": false},
+		squirrel.Lt{"r.starts_at": endsAt},
+		squirrel.Gt{"r.ends_at": startsAt},
+	}
+}
+
+func selectTables(ctx context.Context, q querier, builderSelect squirrel.SelectBuilder) ([]*reservationDesc.Table, error) {
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select tables: %v", err)
+		return nil, errors.New("failed to select tables")
+	}
+	defer rows.Close()
+
+	tables := make([]*reservationDesc.Table, 0)
+	for rows.Next() {
+		t := &reservationDesc.Table{Info: &reservationDesc.TableInfo{}}
+		if err = rows.Scan(&t.Id, &t.Info.Name, &t.Info.Capacity, &t.Info.Area); err != nil {
+			log.Printf("failed to scan table: %v", err)
+			return nil, errors.New("failed to scan table")
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func (s *reservationServer) CreateTable(ctx context.Context, req *reservationDesc.CreateTableRequest) (*reservationDesc.CreateTableResponse, error) {
+	info := req.GetInfo()
+	if info.GetName() == "" {
+		return nil, errors.New("table name is empty")
+	}
+	if info.GetCapacity() <= 0 {
+		return nil, errors.New("table capacity must be positive")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := squirrel.Insert("tables").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("name", "capacity", "area").
+		Values(info.GetName(), info.GetCapacity(), info.GetArea()).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var id int32
+	if err = pool.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		log.Printf("failed to insert table: %v", err)
+		return nil, errors.New("failed to insert table")
+	}
+	return &reservationDesc.CreateTableResponse{Id: id}, nil
+}
+
+func (s *reservationServer) ListTables(ctx context.Context, req *reservationDesc.ListTablesRequest) (*reservationDesc.ListTablesResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	builderSelect := squirrel.Select("id", "name", "capacity", "area").
+		From("tables").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("area", "name")
+	if req.GetArea() != "" {
+		builderSelect = builderSelect.Where(squirrel.Eq{"area": req.GetArea()})
+	}
+
+	tables, err := selectTables(ctx, pool, builderSelect)
+	if err != nil {
+		return nil, err
+	}
+	return &reservationDesc.ListTablesResponse{Tables: tables}, nil
+}
+
+func (s *reservationServer) DeleteTable(ctx context.Context, req *reservationDesc.DeleteTableRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := squirrel.Select("count(*)").
+		From("reservations r").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"r.table_id": req.GetId(), "r.cancelled": false}).
+		Where(squirrel.Gt{"r.ends_at": time.Now().UTC()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var upcoming int
+	if err = pool.QueryRow(ctx, query, args...).Scan(&upcoming); err != nil {
+		log.Printf("failed to count reservations: %v", err)
+		return nil, errors.New("failed to count reservations")
+	}
+	if upcoming > 0 {
+		return nil, errors.New("table has upcoming reservations")
+	}
+
+	query, args, err = squirrel.Delete("tables").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete table: %v", err)
+		return nil, errors.New("failed to delete table")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no table with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *reservationServer) CreateReservation(ctx context.Context, req *reservationDesc.CreateReservationRequest) (*reservationDesc.CreateReservationResponse, error) {
+	info := req.GetInfo()
+	if info.GetStartsAt() == nil || info.GetEndsAt() == nil {
+		return nil, errors.New("reservation time is not set")
+	}
+	startsAt := info.GetStartsAt().AsTime()
+	endsAt := info.GetEndsAt().AsTime()
+	if !startsAt.Before(endsAt) {
+		return nil, errors.New("reservation ends before it starts")
+	}
+	if info.GetGuests() <= 0 {
+		return nil, errors.New("number of guests must be positive")
+	}
+	if info.GetPersonId() == 0 && info.GetGuestName() == "" {
+		return nil, errors.New("reservation needs a person or a guest name")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if info.GetPersonId() != 0 {
+		if err = checkExists(ctx, pool, "persons", info.GetPersonId()); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the table row serializes concurrent bookings of the same table.
+	query, args, err := squirrel.Select("capacity").
+		From("tables").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": info.GetTableId()}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var capacity int32
+	err = tx.QueryRow(ctx, query, args...).Scan(&capacity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("there is no table with such id in system")
+	} else if err != nil {
+		log.Printf("failed to select table: %v", err)
+		return nil, errors.New("failed to select table")
+	}
+	if info.GetGuests() > capacity {
+		return nil, errors.New("table is too small for this number of guests")
+	}
+
+	query, args, err = squirrel.Select("count(*)").
+		From("reservations r").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"r.table_id": info.GetTableId()}).
+		Where(overlaps(startsAt, endsAt)).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var conflicts int
+	if err = tx.QueryRow(ctx, query, args...).Scan(&conflicts); err != nil {
+		log.Printf("failed to count reservations: %v", err)
+		return nil, errors.New("failed to count reservations")
+	}
+	if conflicts > 0 {
+		return nil, status.Error(codes.FailedPrecondition, constraintDescriptions["reservations_no_overlap"])
+	}
+
+	var personId interface{}
+	if info.GetPersonId() != 0 {
+		personId = info.GetPersonId()
+	}
+	query, args, err = squirrel.Insert("reservations").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("table_id", "person_id", "guest_name", "guests", "starts_at", "ends_at", "comment", "cancelled", "created_at").
+		Values(info.GetTableId(), personId, info.GetGuestName(), info.GetGuests(), startsAt, endsAt, info.GetComment(), false, sqlNow).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+	var id int32
+	if err = tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		return nil, constraintError(err, "failed to insert reservation")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to insert reservation")
+	}
+	return &reservationDesc.CreateReservationResponse{Id: id}, nil
+}
+
+func (s *reservationServer) CancelReservation(ctx context.Context, req *reservationDesc.CancelReservationRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := squirrel.Update("reservations").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("cancelled", true).
+		Where(squirrel.Eq{"id": req.GetId(), "cancelled": false}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to cancel reservation: %v", err)
+		return nil, errors.New("failed to cancel reservation")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no active reservation with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *reservationServer) ListReservations(ctx context.Context, req *reservationDesc.ListReservationsRequest) (*reservationDesc.ListReservationsResponse, error) {
+	day, err := time.Parse("2006-01-02", req.GetDate())
+	if err != nil {
+		return nil, errors.New("date must be in YYYY-MM-DD format")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	builderSelect := squirrel.Select("id", "table_id", "person_id", "guest_name", "guests", "starts_at", "ends_at", "comment", "cancelled", "created_at").
+		From("reservations r").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Lt{"r.starts_at": day.AddDate(0, 0, 1)}).
+		Where(squirrel.Gt{"r.ends_at": day}).
+		OrderBy("starts_at", "table_id")
+	if req.GetTableId() != 0 {
+		builderSelect = builderSelect.Where(squirrel.Eq{"r.table_id": req.GetTableId()})
+	}
+	if !req.GetIncludeCancelled() {
+		builderSelect = builderSelect.Where(squirrel.Eq{"r.cancelled": false})
+	}
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select reservations: %v", err)
+		return nil, errors.New("failed to select reservations")
+	}
+	defer rows.Close()
+
+	reservations := make([]*reservationDesc.Reservation, 0)
+	for rows.Next() {
+		r := &reservationDesc.Reservation{Info: &reservationDesc.ReservationInfo{}}
+		var personId *int32
+		var startsAt, endsAt, createdAt time.Time
+		err = rows.Scan(&r.Id, &r.Info.TableId, &personId, &r.Info.GuestName, &r.Info.Guests, &startsAt, &endsAt, &r.Info.Comment, &r.Cancelled, &createdAt)
+		if err != nil {
+			log.Printf("failed to scan reservation: %v", err)
+			return nil, errors.New("failed to scan reservation")
+		}
+		if personId != nil {
+			r.Info.PersonId = *personId
+		}
+		r.Info.StartsAt = timestamppb.New(startsAt)
+		r.Info.EndsAt = timestamppb.New(endsAt)
+		r.CreatedAt = timestamppb.New(createdAt)
+		reservations = append(reservations, r)
+	}
+	return &reservationDesc.ListReservationsResponse{Reservations: reservations}, nil
+}
+
+func (s *reservationServer) FindFreeTables(ctx context.Context, req *reservationDesc.FindFreeTablesRequest) (*reservationDesc.FindFreeTablesResponse, error) {
+	if req.GetStartsAt() == nil || req.GetEndsAt() == nil {
+		return nil, errors.New("time range is not set")
+	}
+	startsAt := req.GetStartsAt().AsTime()
+	endsAt := req.GetEndsAt().AsTime()
+	if !startsAt.Before(endsAt) {
+		return nil, errors.New("time range ends before it starts")
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	busy := squirrel.Select("1").
+		From("reservations r").
+		Where("r.table_id = t.id").
+		Where(overlaps(startsAt, endsAt)).
+		Prefix("NOT EXISTS (").
+		Suffix(")")
+	builderSelect := squirrel.Select("t.id", "t.name", "t.capacity", "t.area").
+		From("tables t").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(busy).
+		Where(squirrel.GtOrEq{"t.capacity": req.GetGuests()}).
+		OrderBy("t.capacity", "t.area", "t.name")
+	if req.GetArea() != "" {
+		builderSelect = builderSelect.Where(squirrel.Eq{"t.area": req.GetArea()})
+	}
+
+	tables, err := selectTables(ctx, pool, builderSelect)
+	if err != nil {
+		return nil, err
+	}
+	return &reservationDesc.FindFreeTablesResponse{Tables: tables}, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"log"
+	"os"
+	"time"
+)
+
+// Deleted dishes stay in the trash for the retention period and are hard-deleted by the purge job afterwards.
+const (
+	dishRetentionEnv       = "DISH_RETENTION"
+	dishPurgeIntervalEnv   = "DISH_PURGE_INTERVAL"
+	defaultDishRetention   = 30 * 24 * time.Hour
+	defaultDishPurgePeriod = time.Hour
+)
+
+var notDeleted = squirrel.Expr("deleted_at IS NULL")
+
+// checkDishExists is checkExists for dishes which are not in the trash.
+func checkDishExists(ctx context.Context, q querier, id int32) error {
+	query, args, err := squirrel.Select("id").
+		From("dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": id}).
+		Where(notDeleted).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+
+	err = q.QueryRow(ctx, query, args...).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("there is no dish with such id in system")
+	} else if err != nil {
+		log.Printf("failed to select dish: %v", err)
+		return errors.New("failed to select dish")
+	}
+	return nil
+}
+
+func (s *server) RestoreDish(ctx context.Context, req *desc.RestoreDishRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := rowSnapshot(ctx, tx, "dishes", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := squirrel.Update("dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("deleted_at", nil).
+		Set("updated_at", sqlNow).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		Where("deleted_at IS NOT NULL").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to restore dish: %v", err)
+		return nil, errors.New("failed to restore dish")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no deleted dish with such id in system")
+	}
+
+	after, err := rowSnapshot(ctx, tx, "dishes", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err = recordMutation(ctx, tx, "dishes", req.GetId(), "restore", before, after); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return nil, errors.New("failed to restore dish")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s %q, using %v", name, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// purgeDeletedDishes hard-deletes dishes which were deleted more than retention ago.
+func purgeDeletedDishes(ctx context.Context, retention time.Duration) error {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	query, args, err := purgeDeletedDishesDelete(time.Now(), retention).ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to purge dishes: %v", err)
+		return errors.New("failed to purge dishes")
+	}
+	if res.RowsAffected() > 0 {
+		log.Printf("purged %d deleted dishes", res.RowsAffected())
+	}
+	return nil
+}
+
+// purgeDeletedDishesDelete deletes dishes which were moved to the trash before now - retention.
+func purgeDeletedDishesDelete(now time.Time, retention time.Duration) squirrel.DeleteBuilder {
+	return squirrel.Delete("dishes").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Lt{"deleted_at": now.Add(-retention).UTC()})
+}
+
+func startPurgeJob() {
+	retention := durationFromEnv(dishRetentionEnv, defaultDishRetention)
+	interval := durationFromEnv(dishPurgeIntervalEnv, defaultDishPurgePeriod)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purgeDeletedDishes(context.Background(), retention)
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPurgeCutoff(t *testing.T) {
+	now := time.Date(2026, 5, 31, 3, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	tests := []struct {
+		retention time.Duration
+		cutoff    time.Time
+	}{
+		{defaultDishRetention, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Hour, time.Date(2026, 5, 30, 23, 0, 0, 0, time.UTC)},
+		{time.Second, time.Date(2026, 5, 30, 23, 59, 59, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		query, args, err := purgeDeletedDishesDelete(now, tt.retention).ToSql()
+		if err != nil {
+			t.Fatalf("retention %v: %v", tt.retention, err)
+		}
+		// Dishes deleted exactly at the cutoff stay, dishes which are not deleted never match.
+		if want := "DELETE FROM dishes WHERE deleted_at < $1"; query != want {
+			t.Errorf("retention %v: query %q, want %q", tt.retention, query, want)
+		}
+		if len(args) != 1 || !reflect.DeepEqual(args[0], tt.cutoff) {
+			t.Errorf("retention %v: cutoff %v, want %v", tt.retention, args, tt.cutoff)
+		}
+	}
+}
+
+func TestDurationFromEnv(t *testing.T) {
+	const name = "TEST_DISH_RETENTION"
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultDishRetention},
+		{"72h", 72 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"0s", defaultDishRetention},
+		{"-1h", defaultDishRetention},
+		{"30 days", defaultDishRetention},
+	}
+	for _, tt := range tests {
+		t.Setenv(name, tt.value)
+		if got := durationFromEnv(name, defaultDishRetention); got != tt.want {
+			t.Errorf("%s=%q: %v, want %v", name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestListDishesSelect(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *desc.ListRequest
+		trash      bool
+		favourites bool
+	}{
+		{"menu", &desc.ListRequest{}, false, false},
+		{"with the trash", &desc.ListRequest{ShowDeleted: true}, true, false},
+		{"favourites", &desc.ListRequest{FavouriteOf: wrapperspb.Int32(5)}, false, true},
+	}
+	for _, tt := range tests {
+		query, args, err := listDishesSelect(tt.req).ToSql()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if strings.Contains(query, "deleted_at IS NULL") == tt.trash {
+			t.Errorf("%s: %q, trash shown %v", tt.name, query, tt.trash)
+		}
+		if strings.Contains(query, "FROM favourites WHERE person_id = $1") != tt.favourites || (tt.favourites && args[0] != int32(5)) {
+			t.Errorf("%s: %q %v, favourites only %v", tt.name, query, args, tt.favourites)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"math"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// fieldRule checks a single field value and returns a violation description, or "" if the value is valid.
+type fieldRule func(value protoreflect.Value) string
+
+var personPositions = []string{"user", "cook", "manager", "admin"}
+
+// validationRules declares the constraints of request fields by message and field name.
+// Fields of wrapper types are checked only when they are set, nested messages are checked recursively
+// and rules of repeated scalar fields apply to every item.
+var validationRules = map[protoreflect.FullName]map[protoreflect.Name][]fieldRule{
+	"dish_v1.DishInfo": {
+		"name":        {required, maxLength(100)},
+		"price":       {minValue(0)},
+		"description": {maxLength(2000)},
+		"composition": {maxLength(2000)},
+		"author":      {minValue(0)},
+		"photo_url":   {maxLength(2048), httpURL},
+		"category":    {maxLength(50)},
+		"allergens":   {required, maxLength(50)},
+	},
+	"dish_v1.UpdateDishInfo": {
+		"name":        {required, maxLength(100)},
+		"price":       {minValue(0), maxValue(math.MaxInt32)},
+		"description": {maxLength(2000)},
+		"composition": {maxLength(2000)},
+		"author":      {minValue(1), maxValue(math.MaxInt32)},
+		"photo_url":   {maxLength(2048), httpURL},
+		"category":    {maxLength(50)},
+	},
+	"dish_v1.CreatePersonReqest": {
+		"login":    {required, maxLength(50)},
+		"password": {minLength(6), maxLength(72)},
+		"position": {oneOf(personPositions...)},
+	},
+	"dish_v1.LogInPersonRequest": {
+		"login":    {required},
+		"password": {required},
+	},
+	"dish_v1.ChangePersonPositionRequest": {
+		"id":       {minValue(1)},
+		"position": {oneOf(personPositions...)},
+	},
+	"dish_v1.OrderLineInfo": {
+		"quantity": {minValue(1), maxValue(maxOrderQuantity)},
+	},
+	"dish_v1.ListAuditEventsRequest": {
+		"limit": {minValue(0), maxValue(maxAuditLimit)},
+	},
+	"dish_v1.WebhookSubscriptionInfo": {
+		"url":    {required, maxLength(2048), httpURL},
+		"secret": {maxLength(200)},
+	},
+	"dish_v1.ListWebhookDeliveriesRequest": {
+		"limit": {minValue(0), maxValue(maxDeliveriesLimit)},
+	},
+}
+
+func required(value protoreflect.Value) string {
+	if s, ok := value.Interface().(string); ok && strings.TrimSpace(s) == "" {
+		return "must not be empty"
+	}
+	return ""
+}
+
+func minLength(n int) fieldRule {
+	return func(value protoreflect.Value) string {
+		if utf8.RuneCountInString(value.String()) < n {
+			return fmt.Sprintf("must be at least %d characters long", n)
+		}
+		return ""
+	}
+}
+
+func maxLength(n int) fieldRule {
+	return func(value protoreflect.Value) string {
+		if utf8.RuneCountInString(value.String()) > n {
+			return fmt.Sprintf("must be at most %d characters long", n)
+		}
+		return ""
+	}
+}
+
+func minValue(n int64) fieldRule {
+	return func(value protoreflect.Value) string {
+		if value.Int() < n {
+			return fmt.Sprintf("must be greater than or equal to %d", n)
+		}
+		return ""
+	}
+}
+
+func maxValue(n int64) fieldRule {
+	return func(value protoreflect.Value) string {
+		if value.Int() > n {
+			return fmt.Sprintf("must be less than or equal to %d", n)
+		}
+		return ""
+	}
+}
+
+func oneOf(values ...string) fieldRule {
+	return func(value protoreflect.Value) string {
+		for _, v := range values {
+			if value.String() == v {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}
+}
+
+// httpURL accepts an empty value or an absolute http(s) URL.
+func httpURL(value protoreflect.Value) string {
+	if value.String() == "" {
+		return ""
+	}
+	u, err := url.Parse(value.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "must be an absolute http or https URL"
+	}
+	return ""
+}
+
+// maskedFields lists message fields validated only on the paths of a sibling field mask,
+// unmasked fields are not written and may hold zero values.
+var maskedFields = map[protoreflect.FullName]map[protoreflect.Name]protoreflect.Name{
+	"dish_v1.UpdateRequest": {"dish": "update_mask"},
+}
+
+// batchItemFields lists repeated fields whose items are validated by the handler,
+// so that per-item batches can report invalid items instead of failing as a whole.
+var batchItemFields = map[protoreflect.FullName]protoreflect.Name{
+	"dish_v1.BatchCreateDishesRequest": "dishes",
+}
+
+func isWrapperMessage(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Path() == "google/protobuf/wrappers.proto"
+}
+
+// collectViolations checks the fields of m, or only the fields in paths when paths is not nil.
+func collectViolations(m protoreflect.Message, prefix string, paths map[string]bool, violations []*errdetails.BadRequest_FieldViolation) []*errdetails.BadRequest_FieldViolation {
+	rules := validationRules[m.Descriptor().FullName()]
+	masks := maskedFields[m.Descriptor().FullName()]
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if paths != nil && !paths[string(fd.Name())] {
+			continue
+		}
+		path := prefix + string(fd.Name())
+		value := m.Get(fd)
+
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsMap() {
+			switch {
+			case fd.IsList():
+				if batchItemFields[m.Descriptor().FullName()] == fd.Name() {
+					continue
+				}
+				for j := 0; j < value.List().Len(); j++ {
+					violations = collectViolations(value.List().Get(j).Message(), fmt.Sprintf("%s[%d].", path, j), nil, violations)
+				}
+				continue
+			case isWrapperMessage(fd.Message()):
+				if !m.Has(fd) {
+					continue
+				}
+				wrapped := value.Message()
+				value = wrapped.Get(wrapped.Descriptor().Fields().ByName("value"))
+			default:
+				if maskName, ok := masks[fd.Name()]; ok {
+					masked := make(map[string]bool)
+					if maskField := m.Descriptor().Fields().ByName(maskName); m.Has(maskField) {
+						mask := m.Get(maskField).Message()
+						maskPaths := mask.Get(mask.Descriptor().Fields().ByName("paths")).List()
+						for j := 0; j < maskPaths.Len(); j++ {
+							masked[maskPaths.Get(j).String()] = true
+						}
+					}
+					violations = collectViolations(value.Message(), path+".", masked, violations)
+				} else if m.Has(fd) {
+					violations = collectViolations(value.Message(), path+".", nil, violations)
+				}
+				continue
+			}
+		}
+
+		if fd.IsList() {
+			for j := 0; j < value.List().Len(); j++ {
+				violations = checkRules(rules[fd.Name()], value.List().Get(j), fmt.Sprintf("%s[%d]", path, j), violations)
+			}
+			continue
+		}
+		violations = checkRules(rules[fd.Name()], value, path, violations)
+	}
+	return violations
+}
+
+// checkRules adds the violation of the first failing rule.
+func checkRules(rules []fieldRule, value protoreflect.Value, path string, violations []*errdetails.BadRequest_FieldViolation) []*errdetails.BadRequest_FieldViolation {
+	for _, rule := range rules {
+		if description := rule(value); description != "" {
+			return append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       path,
+				Description: description,
+			})
+		}
+	}
+	return violations
+}
+
+// validateRequest returns an InvalidArgument status with BadRequest details listing every violated field.
+func validateRequest(msg proto.Message) error {
+	return violationsError(collectViolations(msg.ProtoReflect(), "", nil, nil))
+}
+
+// violationsError returns nil when there are no violations.
+func violationsError(violations []*errdetails.BadRequest_FieldViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	st, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid request")
+	}
+	return st.Err()
+}
+
+func validationInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if msg, ok := req.(proto.Message); ok {
+		if err := validateRequest(msg); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/netguard"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Webhook deliveries are queued when the relay publishes an event to the in-process bus,
+// so the bus sink must be enabled in OUTBOX_SINKS. A delivery which keeps failing is retried
+// with exponential backoff and marked as failed after webhookMaxAttempts, it can be replayed later.
+// A batch is posted webhookConcurrency deliveries at a time and must finish within webhookLease.
+// Receivers must be public addresses, deliveries to loopback, private or link-local ones are refused.
+const (
+	webhookPollIntervalEnv     = "WEBHOOK_POLL_INTERVAL"
+	defaultWebhookPollInterval = time.Second
+	webhookBatchSize           = 50
+	webhookTimeout             = 10 * time.Second
+	webhookConcurrency         = 8
+	webhookLease               = 2 * time.Minute
+	webhookMaxAttempts         = 8
+	webhookBaseDelay           = 10 * time.Second
+	webhookMaxDelay            = time.Hour
+	webhookMaxErrorLength      = 1000
+	defaultDeliveriesLimit     = 100
+	maxDeliveriesLimit         = 1000
+)
+
+// Headers of a delivery, the signature is "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">".
+const (
+	webhookIdHeader        = "X-Webhook-Id"
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	deliveryPending   = "pending"
+	deliverySucceeded = "succeeded"
+	deliveryFailed    = "failed"
+)
+
+func (s *server) CreateWebhookSubscription(ctx context.Context, req *desc.CreateWebhookSubscriptionRequest) (*desc.CreateWebhookSubscriptionResponse, error) {
+	info := req.GetInfo()
+	for _, eventType := range info.GetEventTypes() {
+		if !isEventType(eventType) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown event type %q", eventType)
+		}
+	}
+	if err := netguard.CheckURL(ctx, info.GetUrl()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "webhook url must be a public http or https address: %v", err)
+	}
+
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	secret := info.GetSecret()
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err = rand.Read(b); err != nil {
+			log.Printf("failed to generate secret: %v", err)
+			return nil, errors.New("failed to generate secret")
+		}
+		secret = hex.EncodeToString(b)
+	}
+	eventTypes := info.GetEventTypes()
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	query, args, err := squirrel.Insert("webhook_subscriptions").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("url", "event_types", "secret", "created_at").
+		Values(info.GetUrl(), eventTypes, secret, sqlNow).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	var id int32
+	if err = pool.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		log.Printf("failed to insert webhook subscription: %v", err)
+		return nil, errors.New("failed to insert webhook subscription")
+	}
+	return &desc.CreateWebhookSubscriptionResponse{Id: id, Secret: secret}, nil
+}
+
+func (s *server) ListWebhookSubscriptions(ctx context.Context, req *desc.ListWebhookSubscriptionsRequest) (*desc.ListWebhookSubscriptionsResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	query, args, err := squirrel.Select("id", "url", "event_types", "created_at").
+		From("webhook_subscriptions").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select webhook subscriptions: %v", err)
+		return nil, errors.New("failed to select webhook subscriptions")
+	}
+	defer rows.Close()
+
+	var subscriptions []*desc.WebhookSubscription
+	for rows.Next() {
+		info := &desc.WebhookSubscriptionInfo{}
+		var id int32
+		var createdAt time.Time
+		if err = rows.Scan(&id, &info.Url, &info.EventTypes, &createdAt); err != nil {
+			log.Printf("failed to scan webhook subscription: %v", err)
+			return nil, errors.New("failed to scan webhook subscription")
+		}
+		subscriptions = append(subscriptions, &desc.WebhookSubscription{
+			Id:        id,
+			Info:      info,
+			CreatedAt: timestamppb.New(createdAt),
+		})
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read webhook subscriptions: %v", err)
+		return nil, errors.New("failed to read webhook subscriptions")
+	}
+	return &desc.ListWebhookSubscriptionsResponse{Subscriptions: subscriptions}, nil
+}
+
+func (s *server) DeleteWebhookSubscription(ctx context.Context, req *desc.DeleteWebhookSubscriptionRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	query, args, err := squirrel.Delete("webhook_subscriptions").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"id": req.GetId()}).
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to delete webhook subscription: %v", err)
+		return nil, errors.New("failed to delete webhook subscription")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no webhook subscription with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListWebhookDeliveries(ctx context.Context, req *desc.ListWebhookDeliveriesRequest) (*desc.ListWebhookDeliveriesResponse, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	limit := req.GetLimit()
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+
+	builderSelect := squirrel.Select("id", "subscription_id", "event_id", "event_type", "status", "attempts", "last_status_code", "last_error", "next_attempt_at", "delivered_at", "created_at").
+		From("webhook_deliveries").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("id DESC").
+		Limit(uint64(limit))
+	if req.GetSubscriptionId() != nil {
+		builderSelect = builderSelect.Where(squirrel.Eq{"subscription_id": req.GetSubscriptionId().GetValue()})
+	}
+	if req.GetStatus() != "" {
+		builderSelect = builderSelect.Where(squirrel.Eq{"status": req.GetStatus()})
+	}
+
+	query, args, err := builderSelect.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to select webhook deliveries: %v", err)
+		return nil, errors.New("failed to select webhook deliveries")
+	}
+	defer rows.Close()
+
+	var deliveries []*desc.WebhookDelivery
+	for rows.Next() {
+		d := &desc.WebhookDelivery{}
+		var statusCode *int32
+		var lastError *string
+		var nextAttemptAt, deliveredAt *time.Time
+		var createdAt time.Time
+		err = rows.Scan(&d.Id, &d.SubscriptionId, &d.EventId, &d.EventType, &d.Status, &d.Attempts, &statusCode, &lastError, &nextAttemptAt, &deliveredAt, &createdAt)
+		if err != nil {
+			log.Printf("failed to scan webhook delivery: %v", err)
+			return nil, errors.New("failed to scan webhook delivery")
+		}
+		if statusCode != nil {
+			d.LastStatusCode = *statusCode
+		}
+		if lastError != nil {
+			d.LastError = *lastError
+		}
+		if nextAttemptAt != nil {
+			d.NextAttemptAt = timestamppb.New(*nextAttemptAt)
+		}
+		if deliveredAt != nil {
+			d.DeliveredAt = timestamppb.New(*deliveredAt)
+		}
+		d.CreatedAt = timestamppb.New(createdAt)
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read webhook deliveries: %v", err)
+		return nil, errors.New("failed to read webhook deliveries")
+	}
+	return &desc.ListWebhookDeliveriesResponse{Deliveries: deliveries}, nil
+}
+
+func (s *server) ReplayWebhookDelivery(ctx context.Context, req *desc.ReplayWebhookDeliveryRequest) (*emptypb.Empty, error) {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	if err = requireManager(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	query, args, err := replayWebhookUpdate(req.GetId()).ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	res, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to replay webhook delivery: %v", err)
+		return nil, errors.New("failed to replay webhook delivery")
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("there is no finished webhook delivery with such id in system")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// replayWebhookUpdate queues a finished delivery again with a fresh budget of attempts,
+// the receiver gets it with the same delivery id.
+func replayWebhookUpdate(id int64) squirrel.UpdateBuilder {
+	return squirrel.Update("webhook_deliveries").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("status", deliveryPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", sqlNow).
+		Set("locked_until", nil).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"status": deliveryPending})
+}
+
+func isEventType(eventType string) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueWebhookDeliveries queues a delivery of the event for every matching subscription in
+// the database of the relay. The relay may publish an event again, a subscription gets every event only once.
+func enqueueWebhookDeliveries(ctx context.Context, e execer, event domainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := squirrel.Select("id").
+		Column(squirrel.Expr("?::bigint", event.Id)).
+		Column(squirrel.Expr("?::text", event.Type)).
+		Column(squirrel.Expr("?::jsonb", string(body))).
+		Column(squirrel.Expr("?::text", deliveryPending)).
+		Column(sqlNow).
+		Column(sqlNow).
+		From("webhook_subscriptions").
+		Where(squirrel.Expr("(cardinality(event_types) = 0 OR ? = ANY(event_types))", event.Type))
+
+	query, args, err := squirrel.Insert("webhook_deliveries").
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("subscription_id", "event_id", "event_type", "payload", "status", "next_attempt_at", "created_at").
+		Select(subscriptions).
+		Suffix("ON CONFLICT (subscription_id, event_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(ctx, query, args...)
+	return err
+}
+
+// signWebhook returns the signature header value of a delivery body sent at t.
+func signWebhook(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook posts a signed delivery and returns the response status code, 0 when there was no response.
+func deliverWebhook(ctx context.Context, client *http.Client, url, secret string, deliveryId int64, eventType string, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookIdHeader, strconv.FormatInt(deliveryId, 10))
+	req.Header.Set(webhookEventHeader, eventType)
+	req.Header.Set(webhookSignatureHeader, signWebhook(secret, time.Now(), body))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("receiver responded with %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// webhookDelivery is a claimed delivery with the url and the secret of its subscription.
+type webhookDelivery struct {
+	id        int64
+	eventType string
+	body      []byte
+	attempts  int32
+	url       string
+	secret    string
+}
+
+// webhookResult is the outcome of posting a claimed delivery, a delivery which was not attempted
+// because the lease ran out is skipped.
+type webhookResult struct {
+	delivery   webhookDelivery
+	skipped    bool
+	statusCode int
+	err        error
+}
+
+// processWebhookDeliveries attempts a batch of due deliveries. Deliveries are leased in a single
+// statement, posted without holding a transaction, and their results are recorded in a short one.
+func processWebhookDeliveries(ctx context.Context, client *http.Client) error {
+	pool, err := pgxpool.Connect(ctx, dbDSN)
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return errors.New("failed to connect to database")
+	}
+	defer pool.Close()
+
+	deliveries, err := claimWebhookDeliveries(ctx, pool)
+	if err != nil || len(deliveries) == 0 {
+		return err
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, webhookLease)
+	results := deliverWebhooks(deliverCtx, client, deliveries)
+	cancel()
+
+	return recordWebhookResults(ctx, pool, results)
+}
+
+// claimWebhookDeliveries leases up to webhookBatchSize due deliveries for webhookLease.
+// A delivery whose lease ran out, because its worker stopped, is claimed again.
+func claimWebhookDeliveries(ctx context.Context, q querier) ([]webhookDelivery, error) {
+	due := squirrel.Select("id").
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"status": deliveryPending}).
+		Where("next_attempt_at <= now()").
+		Where("(locked_until IS NULL OR locked_until < now())").
+		OrderBy("id").
+		Limit(webhookBatchSize).
+		Suffix("FOR UPDATE SKIP LOCKED")
+	dueSql, dueArgs, err := due.ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	claimSql, claimArgs, err := squirrel.Update("webhook_deliveries").
+		Set("locked_until", squirrel.Expr("now() + make_interval(secs => ?)", webhookLease.Seconds())).
+		Where("id IN ("+dueSql+")", dueArgs...).
+		Suffix("RETURNING id, subscription_id, event_type, payload, attempts").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	query, args, err := squirrel.Select("c.id", "c.event_type", "c.payload", "c.attempts", "s.url", "s.secret").
+		Prefix("WITH claimed AS ("+claimSql+")", claimArgs...).
+		From("claimed c").
+		Join("webhook_subscriptions s ON s.id = c.subscription_id").
+		PlaceholderFormat(squirrel.Dollar).
+		OrderBy("c.id").
+		ToSql()
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return nil, errors.New("failed to build query")
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("failed to claim webhook deliveries: %v", err)
+		return nil, errors.New("failed to claim webhook deliveries")
+	}
+	defer rows.Close()
+
+	var deliveries []webhookDelivery
+	for rows.Next() {
+		var d webhookDelivery
+		if err = rows.Scan(&d.id, &d.eventType, &d.body, &d.attempts, &d.url, &d.secret); err != nil {
+			log.Printf("failed to scan webhook delivery: %v", err)
+			return nil, errors.New("failed to scan webhook delivery")
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("failed to read webhook deliveries: %v", err)
+		return nil, errors.New("failed to read webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+// deliverWebhooks posts the deliveries, at most webhookConcurrency at a time. Deliveries which
+// have not started when ctx is done are skipped.
+func deliverWebhooks(ctx context.Context, client *http.Client, deliveries []webhookDelivery) []webhookResult {
+	results := make([]webhookResult, len(deliveries))
+	sem := make(chan struct{}, webhookConcurrency)
+	var wg sync.WaitGroup
+	for i, d := range deliveries {
+		results[i].delivery = d
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			results[i].skipped = true
+			continue
+		}
+		wg.Add(1)
+		go func(r *webhookResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.statusCode, r.err = deliverWebhook(ctx, client, d.url, d.secret, d.id, d.eventType, d.body)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// webhookResultUpdate releases the lease of a delivery and records its result.
+func webhookResultUpdate(result webhookResult, now time.Time) squirrel.UpdateBuilder {
+	builderUpdate := squirrel.Update("webhook_deliveries").
+		PlaceholderFormat(squirrel.Dollar).
+		Set("locked_until", nil).
+		Where(squirrel.Eq{"id": result.delivery.id})
+	if result.skipped {
+		return builderUpdate
+	}
+
+	attempts := result.delivery.attempts + 1
+	builderUpdate = builderUpdate.Set("attempts", attempts)
+	if result.statusCode != 0 {
+		builderUpdate = builderUpdate.Set("last_status_code", result.statusCode)
+	}
+	switch {
+	case result.err == nil:
+		builderUpdate = builderUpdate.
+			Set("status", deliverySucceeded).
+			Set("last_error", nil).
+			Set("next_attempt_at", nil).
+			Set("delivered_at", sqlNow)
+	case attempts >= webhookMaxAttempts:
+		builderUpdate = builderUpdate.
+			Set("status", deliveryFailed).
+			Set("last_error", truncateError(result.err, webhookMaxErrorLength)).
+			Set("next_attempt_at", nil)
+	default:
+		builderUpdate = builderUpdate.
+			Set("last_error", truncateError(result.err, webhookMaxErrorLength)).
+			Set("next_attempt_at", now.Add(retryDelay(attempts, webhookBaseDelay, webhookMaxDelay)).UTC())
+	}
+	return builderUpdate
+}
+
+func recordWebhookResults(ctx context.Context, pool *pgxpool.Pool, results []webhookResult) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		log.Printf("failed to begin transaction: %v", err)
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	for _, result := range results {
+		query, args, err := webhookResultUpdate(result, now).ToSql()
+		if err != nil {
+			log.Printf("failed to build query: %v", err)
+			return errors.New("failed to build query")
+		}
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			log.Printf("failed to update webhook delivery: %v", err)
+			return errors.New("failed to update webhook delivery")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction: %v", err)
+		return errors.New("failed to update webhook deliveries")
+	}
+	return nil
+}
+
+// truncateError cuts the message of err to at most n bytes without splitting a UTF-8 character.
+func truncateError(err error, n int) string {
+	message := err.Error()
+	if len(message) <= n {
+		return message
+	}
+	for n > 0 && !utf8.RuneStart(message[n]) {
+		n--
+	}
+	return message[:n]
+}
+
+func startWebhookWorker() {
+	localBus.Subscribe(enqueueWebhookDeliveries)
+	client := netguard.NewClient(webhookTimeout)
+	interval := durationFromEnv(webhookPollIntervalEnv, defaultWebhookPollInterval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			processWebhookDeliveries(context.Background(), client)
+		}
+	}()
+}
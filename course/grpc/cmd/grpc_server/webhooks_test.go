@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// receiver is a local webhook receiver which answers with the status codes of responses in turn
+// and verifies the signature of every request.
+type receiver struct {
+	t         *testing.T
+	secret    string
+	mu        sync.Mutex
+	responses []int
+	ids       []string
+}
+
+func (rv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := verifySignature(rv.secret, r.Header.Get(webhookSignatureHeader), body); err != nil {
+		rv.t.Errorf("delivery %s: %v", r.Header.Get(webhookIdHeader), err)
+	}
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	rv.ids = append(rv.ids, r.Header.Get(webhookIdHeader))
+	code := http.StatusNoContent
+	if len(rv.responses) > 0 {
+		code, rv.responses = rv.responses[0], rv.responses[1:]
+	}
+	w.WriteHeader(code)
+}
+
+// verifySignature checks a signature header the way a receiver documented in webhooks.go would.
+func verifySignature(secret, header string, body []byte) error {
+	timestamp, signature, ok := strings.Cut(header, ",")
+	timestamp, ok1 := strings.CutPrefix(timestamp, "t=")
+	signature, ok2 := strings.CutPrefix(signature, "v1=")
+	if !ok || !ok1 || !ok2 {
+		return errors.New("malformed signature header " + header)
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)) > time.Minute {
+		return errors.New("stale signature timestamp " + timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	sum, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, mac.Sum(nil)) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func newReceiver(t *testing.T, responses ...int) (*receiver, webhookDelivery) {
+	rv := &receiver{t: t, secret: "s3cret", responses: responses}
+	srv := httptest.NewServer(rv)
+	t.Cleanup(srv.Close)
+	return rv, webhookDelivery{id: 42, eventType: eventDishCreated, body: []byte(`{"id":1}`), url: srv.URL, secret: rv.secret}
+}
+
+func TestDeliverWebhookSignature(t *testing.T) {
+	rv, d := newReceiver(t)
+	results := deliverWebhooks(context.Background(), http.DefaultClient, []webhookDelivery{d})
+	if results[0].err != nil || results[0].statusCode != http.StatusNoContent {
+		t.Fatalf("delivery failed: %+v", results[0])
+	}
+	if len(rv.ids) != 1 || rv.ids[0] != "42" {
+		t.Errorf("receiver got deliveries %v, want [42]", rv.ids)
+	}
+	if err := verifySignature("other", signWebhook(rv.secret, time.Now(), d.body), d.body); err == nil {
+		t.Error("signature verified with a wrong secret")
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	_, d := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	now := time.Now()
+
+	result := deliverWebhooks(context.Background(), http.DefaultClient, []webhookDelivery{d})[0]
+	if result.err == nil || result.statusCode != http.StatusInternalServerError {
+		t.Fatalf("want a failed delivery with status 500, got %+v", result)
+	}
+	query := debugSql(t, webhookResultUpdate(result, now))
+	for _, want := range []string{"attempts = '1'", "last_status_code = '500'", "next_attempt_at = '", "locked_until = '<nil>'"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("retry %q does not contain %s", query, want)
+		}
+	}
+	if strings.Contains(query, "status = ") {
+		t.Errorf("retry %q finishes the delivery", query)
+	}
+
+	d.attempts = webhookMaxAttempts - 1
+	result = deliverWebhooks(context.Background(), http.DefaultClient, []webhookDelivery{d})[0]
+	query = debugSql(t, webhookResultUpdate(result, now))
+	for _, want := range []string{"status = '" + deliveryFailed + "'", "next_attempt_at = '<nil>'"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("last attempt %q does not contain %s", query, want)
+		}
+	}
+
+	result = deliverWebhooks(context.Background(), http.DefaultClient, []webhookDelivery{d})[0]
+	query = debugSql(t, webhookResultUpdate(result, now))
+	if !strings.Contains(query, "status = '"+deliverySucceeded+"'") {
+		t.Errorf("successful delivery %q is not succeeded", query)
+	}
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	rv, d := newReceiver(t, http.StatusGone)
+	d.attempts = webhookMaxAttempts - 1
+	result := deliverWebhooks(context.Background(), http.DefaultClient, []webhookDelivery{d})[0]
+	if !strings.Contains(debugSql(t, webhookResultUpdate(result, time.Now())), "status = '"+deliveryFailed+"'") {
+		t.Fatal("delivery did not fail")
+	}
+
+	query := debugSql(t, replayWebhookUpdate(d.id))
+	for _, want := range []string{"status = '" + deliveryPending + "'", "attempts = '0'", "next_attempt_at = now()", "status <> '" + deliveryPending + "'"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("replay %q does not contain %s", query, want)
+		}
+	}
+
+	d.attempts = 0
+	result = deliverWebhooks(context.Background(), http.DefaultClient, []webhookDelivery{d})[0]
+	if result.err != nil {
+		t.Fatalf("replayed delivery failed: %v", result.err)
+	}
+	if len(rv.ids) != 2 || rv.ids[0] != rv.ids[1] {
+		t.Errorf("replay was not sent with the same delivery id: %v", rv.ids)
+	}
+}
+
+func TestDeliverWebhooksStopsAfterLease(t *testing.T) {
+	rv, d := newReceiver(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := deliverWebhooks(ctx, http.DefaultClient, []webhookDelivery{d, d})
+	if len(rv.ids) != 0 || !results[0].skipped || !results[1].skipped {
+		t.Errorf("deliveries posted after the lease: %+v", results)
+	}
+	query := debugSql(t, webhookResultUpdate(results[0], time.Now()))
+	if strings.Contains(query, "attempts") {
+		t.Errorf("skipped delivery %q counts an attempt", query)
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	err := errors.New("блюдо")
+	for n := 0; n <= len(err.Error())+1; n++ {
+		got := truncateError(err, n)
+		if len(got) > n || !utf8.ValidString(got) || !strings.HasPrefix(err.Error(), got) {
+			t.Errorf("truncateError(%d) = %q", n, got)
+		}
+	}
+}
+
+func debugSql(t *testing.T, b squirrel.UpdateBuilder) string {
+	t.Helper()
+	query := squirrel.DebugSqlizer(b.PlaceholderFormat(squirrel.Question))
+	if strings.HasPrefix(query, "[") {
+		t.Fatal(query)
+	}
+	return query
+}
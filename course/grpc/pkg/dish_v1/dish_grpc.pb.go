@@ -28,9 +28,40 @@ type DishV1Client interface {
 	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
 	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	RestoreDish(ctx context.Context, in *RestoreDishRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	BatchCreateDishes(ctx context.Context, in *BatchCreateDishesRequest, opts ...grpc.CallOption) (*BatchCreateDishesResponse, error)
+	BatchGetDishes(ctx context.Context, in *BatchGetDishesRequest, opts ...grpc.CallOption) (*BatchGetDishesResponse, error)
+	BatchDeleteDishes(ctx context.Context, in *BatchDeleteDishesRequest, opts ...grpc.CallOption) (*BatchDeleteDishesResponse, error)
 	CreatePerson(ctx context.Context, in *CreatePersonReqest, opts ...grpc.CallOption) (*CreatePersonResponse, error)
 	LogInPerson(ctx context.Context, in *LogInPersonRequest, opts ...grpc.CallOption) (*LogInPersonResponce, error)
 	ChangePersonPosition(ctx context.Context, in *ChangePersonPositionRequest, opts ...grpc.CallOption) (*ChangePersonPositionResponse, error)
+	AddFavourite(ctx context.Context, in *AddFavouriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	RemoveFavourite(ctx context.Context, in *RemoveFavouriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListFavourites(ctx context.Context, in *ListFavouritesRequest, opts ...grpc.CallOption) (*ListFavouritesResponse, error)
+	CreateCollection(ctx context.Context, in *CreateCollectionRequest, opts ...grpc.CallOption) (*CreateCollectionResponse, error)
+	DeleteCollection(ctx context.Context, in *DeleteCollectionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListCollections(ctx context.Context, in *ListCollectionsRequest, opts ...grpc.CallOption) (*ListCollectionsResponse, error)
+	AddDishToCollection(ctx context.Context, in *AddDishToCollectionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	RemoveDishFromCollection(ctx context.Context, in *RemoveDishFromCollectionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreatePromotion(ctx context.Context, in *CreatePromotionRequest, opts ...grpc.CallOption) (*CreatePromotionResponse, error)
+	ListPromotions(ctx context.Context, in *ListPromotionsRequest, opts ...grpc.CallOption) (*ListPromotionsResponse, error)
+	DeletePromotion(ctx context.Context, in *DeletePromotionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*CreateOrderResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error)
+	CreateCombo(ctx context.Context, in *CreateComboRequest, opts ...grpc.CallOption) (*CreateComboResponse, error)
+	GetCombo(ctx context.Context, in *GetComboRequest, opts ...grpc.CallOption) (*GetComboResponse, error)
+	ListCombos(ctx context.Context, in *ListCombosRequest, opts ...grpc.CallOption) (*ListCombosResponse, error)
+	UpdateCombo(ctx context.Context, in *UpdateComboRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteCombo(ctx context.Context, in *DeleteComboRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateModifierGroup(ctx context.Context, in *CreateModifierGroupRequest, opts ...grpc.CallOption) (*CreateModifierGroupResponse, error)
+	ListModifierGroups(ctx context.Context, in *ListModifierGroupsRequest, opts ...grpc.CallOption) (*ListModifierGroupsResponse, error)
+	DeleteModifierGroup(ctx context.Context, in *DeleteModifierGroupRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	CreateWebhookSubscription(ctx context.Context, in *CreateWebhookSubscriptionRequest, opts ...grpc.CallOption) (*CreateWebhookSubscriptionResponse, error)
+	ListWebhookSubscriptions(ctx context.Context, in *ListWebhookSubscriptionsRequest, opts ...grpc.CallOption) (*ListWebhookSubscriptionsResponse, error)
+	DeleteWebhookSubscription(ctx context.Context, in *DeleteWebhookSubscriptionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error)
+	ReplayWebhookDelivery(ctx context.Context, in *ReplayWebhookDeliveryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type dishV1Client struct {
@@ -86,6 +117,42 @@ func (c *dishV1Client) Delete(ctx context.Context, in *DeleteRequest, opts ...gr
 	return out, nil
 }
 
+func (c *dishV1Client) RestoreDish(ctx context.Context, in *RestoreDishRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/RestoreDish", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) BatchCreateDishes(ctx context.Context, in *BatchCreateDishesRequest, opts ...grpc.CallOption) (*BatchCreateDishesResponse, error) {
+	out := new(BatchCreateDishesResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/BatchCreateDishes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) BatchGetDishes(ctx context.Context, in *BatchGetDishesRequest, opts ...grpc.CallOption) (*BatchGetDishesResponse, error) {
+	out := new(BatchGetDishesResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/BatchGetDishes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) BatchDeleteDishes(ctx context.Context, in *BatchDeleteDishesRequest, opts ...grpc.CallOption) (*BatchDeleteDishesResponse, error) {
+	out := new(BatchDeleteDishesResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/BatchDeleteDishes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *dishV1Client) CreatePerson(ctx context.Context, in *CreatePersonReqest, opts ...grpc.CallOption) (*CreatePersonResponse, error) {
 	out := new(CreatePersonResponse)
 	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreatePerson", in, out, opts...)
@@ -113,6 +180,249 @@ func (c *dishV1Client) ChangePersonPosition(ctx context.Context, in *ChangePerso
 	return out, nil
 }
 
+func (c *dishV1Client) AddFavourite(ctx context.Context, in *AddFavouriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/AddFavourite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) RemoveFavourite(ctx context.Context, in *RemoveFavouriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/RemoveFavourite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListFavourites(ctx context.Context, in *ListFavouritesRequest, opts ...grpc.CallOption) (*ListFavouritesResponse, error) {
+	out := new(ListFavouritesResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListFavourites", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) CreateCollection(ctx context.Context, in *CreateCollectionRequest, opts ...grpc.CallOption) (*CreateCollectionResponse, error) {
+	out := new(CreateCollectionResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreateCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) DeleteCollection(ctx context.Context, in *DeleteCollectionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/DeleteCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListCollections(ctx context.Context, in *ListCollectionsRequest, opts ...grpc.CallOption) (*ListCollectionsResponse, error) {
+	out := new(ListCollectionsResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListCollections", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) AddDishToCollection(ctx context.Context, in *AddDishToCollectionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/AddDishToCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) RemoveDishFromCollection(ctx context.Context, in *RemoveDishFromCollectionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/RemoveDishFromCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) CreatePromotion(ctx context.Context, in *CreatePromotionRequest, opts ...grpc.CallOption) (*CreatePromotionResponse, error) {
+	out := new(CreatePromotionResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreatePromotion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListPromotions(ctx context.Context, in *ListPromotionsRequest, opts ...grpc.CallOption) (*ListPromotionsResponse, error) {
+	out := new(ListPromotionsResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListPromotions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) DeletePromotion(ctx context.Context, in *DeletePromotionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/DeletePromotion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*CreateOrderResponse, error) {
+	out := new(CreateOrderResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreateOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error) {
+	out := new(GetOrderResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/GetOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) CreateCombo(ctx context.Context, in *CreateComboRequest, opts ...grpc.CallOption) (*CreateComboResponse, error) {
+	out := new(CreateComboResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreateCombo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) GetCombo(ctx context.Context, in *GetComboRequest, opts ...grpc.CallOption) (*GetComboResponse, error) {
+	out := new(GetComboResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/GetCombo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListCombos(ctx context.Context, in *ListCombosRequest, opts ...grpc.CallOption) (*ListCombosResponse, error) {
+	out := new(ListCombosResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListCombos", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) UpdateCombo(ctx context.Context, in *UpdateComboRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/UpdateCombo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) DeleteCombo(ctx context.Context, in *DeleteComboRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/DeleteCombo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) CreateModifierGroup(ctx context.Context, in *CreateModifierGroupRequest, opts ...grpc.CallOption) (*CreateModifierGroupResponse, error) {
+	out := new(CreateModifierGroupResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreateModifierGroup", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListModifierGroups(ctx context.Context, in *ListModifierGroupsRequest, opts ...grpc.CallOption) (*ListModifierGroupsResponse, error) {
+	out := new(ListModifierGroupsResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListModifierGroups", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) DeleteModifierGroup(ctx context.Context, in *DeleteModifierGroupRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/DeleteModifierGroup", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListAuditEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) CreateWebhookSubscription(ctx context.Context, in *CreateWebhookSubscriptionRequest, opts ...grpc.CallOption) (*CreateWebhookSubscriptionResponse, error) {
+	out := new(CreateWebhookSubscriptionResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/CreateWebhookSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListWebhookSubscriptions(ctx context.Context, in *ListWebhookSubscriptionsRequest, opts ...grpc.CallOption) (*ListWebhookSubscriptionsResponse, error) {
+	out := new(ListWebhookSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListWebhookSubscriptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) DeleteWebhookSubscription(ctx context.Context, in *DeleteWebhookSubscriptionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/DeleteWebhookSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error) {
+	out := new(ListWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ListWebhookDeliveries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dishV1Client) ReplayWebhookDelivery(ctx context.Context, in *ReplayWebhookDeliveryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dish_v1.DishV1/ReplayWebhookDelivery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DishV1Server is the server API for DishV1 service.
 // All implementations must embed UnimplementedDishV1Server
 // for forward compatibility
@@ -122,9 +432,40 @@ type DishV1Server interface {
 	List(context.Context, *ListRequest) (*ListResponse, error)
 	Update(context.Context, *UpdateRequest) (*emptypb.Empty, error)
 	Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error)
+	RestoreDish(context.Context, *RestoreDishRequest) (*emptypb.Empty, error)
+	BatchCreateDishes(context.Context, *BatchCreateDishesRequest) (*BatchCreateDishesResponse, error)
+	BatchGetDishes(context.Context, *BatchGetDishesRequest) (*BatchGetDishesResponse, error)
+	BatchDeleteDishes(context.Context, *BatchDeleteDishesRequest) (*BatchDeleteDishesResponse, error)
 	CreatePerson(context.Context, *CreatePersonReqest) (*CreatePersonResponse, error)
 	LogInPerson(context.Context, *LogInPersonRequest) (*LogInPersonResponce, error)
 	ChangePersonPosition(context.Context, *ChangePersonPositionRequest) (*ChangePersonPositionResponse, error)
+	AddFavourite(context.Context, *AddFavouriteRequest) (*emptypb.Empty, error)
+	RemoveFavourite(context.Context, *RemoveFavouriteRequest) (*emptypb.Empty, error)
+	ListFavourites(context.Context, *ListFavouritesRequest) (*ListFavouritesResponse, error)
+	CreateCollection(context.Context, *CreateCollectionRequest) (*CreateCollectionResponse, error)
+	DeleteCollection(context.Context, *DeleteCollectionRequest) (*emptypb.Empty, error)
+	ListCollections(context.Context, *ListCollectionsRequest) (*ListCollectionsResponse, error)
+	AddDishToCollection(context.Context, *AddDishToCollectionRequest) (*emptypb.Empty, error)
+	RemoveDishFromCollection(context.Context, *RemoveDishFromCollectionRequest) (*emptypb.Empty, error)
+	CreatePromotion(context.Context, *CreatePromotionRequest) (*CreatePromotionResponse, error)
+	ListPromotions(context.Context, *ListPromotionsRequest) (*ListPromotionsResponse, error)
+	DeletePromotion(context.Context, *DeletePromotionRequest) (*emptypb.Empty, error)
+	CreateOrder(context.Context, *CreateOrderRequest) (*CreateOrderResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error)
+	CreateCombo(context.Context, *CreateComboRequest) (*CreateComboResponse, error)
+	GetCombo(context.Context, *GetComboRequest) (*GetComboResponse, error)
+	ListCombos(context.Context, *ListCombosRequest) (*ListCombosResponse, error)
+	UpdateCombo(context.Context, *UpdateComboRequest) (*emptypb.Empty, error)
+	DeleteCombo(context.Context, *DeleteComboRequest) (*emptypb.Empty, error)
+	CreateModifierGroup(context.Context, *CreateModifierGroupRequest) (*CreateModifierGroupResponse, error)
+	ListModifierGroups(context.Context, *ListModifierGroupsRequest) (*ListModifierGroupsResponse, error)
+	DeleteModifierGroup(context.Context, *DeleteModifierGroupRequest) (*emptypb.Empty, error)
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	CreateWebhookSubscription(context.Context, *CreateWebhookSubscriptionRequest) (*CreateWebhookSubscriptionResponse, error)
+	ListWebhookSubscriptions(context.Context, *ListWebhookSubscriptionsRequest) (*ListWebhookSubscriptionsResponse, error)
+	DeleteWebhookSubscription(context.Context, *DeleteWebhookSubscriptionRequest) (*emptypb.Empty, error)
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
+	ReplayWebhookDelivery(context.Context, *ReplayWebhookDeliveryRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedDishV1Server()
 }
 
@@ -147,6 +488,18 @@ func (UnimplementedDishV1Server) Update(context.Context, *UpdateRequest) (*empty
 func (UnimplementedDishV1Server) Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedDishV1Server) RestoreDish(context.Context, *RestoreDishRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreDish not implemented")
+}
+func (UnimplementedDishV1Server) BatchCreateDishes(context.Context, *BatchCreateDishesRequest) (*BatchCreateDishesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateDishes not implemented")
+}
+func (UnimplementedDishV1Server) BatchGetDishes(context.Context, *BatchGetDishesRequest) (*BatchGetDishesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetDishes not implemented")
+}
+func (UnimplementedDishV1Server) BatchDeleteDishes(context.Context, *BatchDeleteDishesRequest) (*BatchDeleteDishesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteDishes not implemented")
+}
 func (UnimplementedDishV1Server) CreatePerson(context.Context, *CreatePersonReqest) (*CreatePersonResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreatePerson not implemented")
 }
@@ -156,6 +509,87 @@ func (UnimplementedDishV1Server) LogInPerson(context.Context, *LogInPersonReques
 func (UnimplementedDishV1Server) ChangePersonPosition(context.Context, *ChangePersonPositionRequest) (*ChangePersonPositionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ChangePersonPosition not implemented")
 }
+func (UnimplementedDishV1Server) AddFavourite(context.Context, *AddFavouriteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddFavourite not implemented")
+}
+func (UnimplementedDishV1Server) RemoveFavourite(context.Context, *RemoveFavouriteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFavourite not implemented")
+}
+func (UnimplementedDishV1Server) ListFavourites(context.Context, *ListFavouritesRequest) (*ListFavouritesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFavourites not implemented")
+}
+func (UnimplementedDishV1Server) CreateCollection(context.Context, *CreateCollectionRequest) (*CreateCollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCollection not implemented")
+}
+func (UnimplementedDishV1Server) DeleteCollection(context.Context, *DeleteCollectionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCollection not implemented")
+}
+func (UnimplementedDishV1Server) ListCollections(context.Context, *ListCollectionsRequest) (*ListCollectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCollections not implemented")
+}
+func (UnimplementedDishV1Server) AddDishToCollection(context.Context, *AddDishToCollectionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddDishToCollection not implemented")
+}
+func (UnimplementedDishV1Server) RemoveDishFromCollection(context.Context, *RemoveDishFromCollectionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveDishFromCollection not implemented")
+}
+func (UnimplementedDishV1Server) CreatePromotion(context.Context, *CreatePromotionRequest) (*CreatePromotionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePromotion not implemented")
+}
+func (UnimplementedDishV1Server) ListPromotions(context.Context, *ListPromotionsRequest) (*ListPromotionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPromotions not implemented")
+}
+func (UnimplementedDishV1Server) DeletePromotion(context.Context, *DeletePromotionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePromotion not implemented")
+}
+func (UnimplementedDishV1Server) CreateOrder(context.Context, *CreateOrderRequest) (*CreateOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrder not implemented")
+}
+func (UnimplementedDishV1Server) GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedDishV1Server) CreateCombo(context.Context, *CreateComboRequest) (*CreateComboResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCombo not implemented")
+}
+func (UnimplementedDishV1Server) GetCombo(context.Context, *GetComboRequest) (*GetComboResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCombo not implemented")
+}
+func (UnimplementedDishV1Server) ListCombos(context.Context, *ListCombosRequest) (*ListCombosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCombos not implemented")
+}
+func (UnimplementedDishV1Server) UpdateCombo(context.Context, *UpdateComboRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCombo not implemented")
+}
+func (UnimplementedDishV1Server) DeleteCombo(context.Context, *DeleteComboRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCombo not implemented")
+}
+func (UnimplementedDishV1Server) CreateModifierGroup(context.Context, *CreateModifierGroupRequest) (*CreateModifierGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateModifierGroup not implemented")
+}
+func (UnimplementedDishV1Server) ListModifierGroups(context.Context, *ListModifierGroupsRequest) (*ListModifierGroupsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModifierGroups not implemented")
+}
+func (UnimplementedDishV1Server) DeleteModifierGroup(context.Context, *DeleteModifierGroupRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteModifierGroup not implemented")
+}
+func (UnimplementedDishV1Server) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedDishV1Server) CreateWebhookSubscription(context.Context, *CreateWebhookSubscriptionRequest) (*CreateWebhookSubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhookSubscription not implemented")
+}
+func (UnimplementedDishV1Server) ListWebhookSubscriptions(context.Context, *ListWebhookSubscriptionsRequest) (*ListWebhookSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookSubscriptions not implemented")
+}
+func (UnimplementedDishV1Server) DeleteWebhookSubscription(context.Context, *DeleteWebhookSubscriptionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhookSubscription not implemented")
+}
+func (UnimplementedDishV1Server) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
+func (UnimplementedDishV1Server) ReplayWebhookDelivery(context.Context, *ReplayWebhookDeliveryRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayWebhookDelivery not implemented")
+}
 func (UnimplementedDishV1Server) mustEmbedUnimplementedDishV1Server() {}
 
 // UnsafeDishV1Server may be embedded to opt out of forward compatibility for this service.
@@ -259,72 +693,630 @@ func _DishV1_Delete_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
-func _DishV1_CreatePerson_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreatePersonReqest)
+func _DishV1_RestoreDish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreDishRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(DishV1Server).CreatePerson(ctx, in)
+		return srv.(DishV1Server).RestoreDish(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/dish_v1.DishV1/CreatePerson",
+		FullMethod: "/dish_v1.DishV1/RestoreDish",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DishV1Server).CreatePerson(ctx, req.(*CreatePersonReqest))
+		return srv.(DishV1Server).RestoreDish(ctx, req.(*RestoreDishRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _DishV1_LogInPerson_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LogInPersonRequest)
+func _DishV1_BatchCreateDishes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateDishesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(DishV1Server).LogInPerson(ctx, in)
+		return srv.(DishV1Server).BatchCreateDishes(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/dish_v1.DishV1/LogInPerson",
+		FullMethod: "/dish_v1.DishV1/BatchCreateDishes",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DishV1Server).LogInPerson(ctx, req.(*LogInPersonRequest))
+		return srv.(DishV1Server).BatchCreateDishes(ctx, req.(*BatchCreateDishesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _DishV1_ChangePersonPosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ChangePersonPositionRequest)
+func _DishV1_BatchGetDishes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetDishesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(DishV1Server).ChangePersonPosition(ctx, in)
+		return srv.(DishV1Server).BatchGetDishes(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/dish_v1.DishV1/ChangePersonPosition",
+		FullMethod: "/dish_v1.DishV1/BatchGetDishes",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DishV1Server).ChangePersonPosition(ctx, req.(*ChangePersonPositionRequest))
+		return srv.(DishV1Server).BatchGetDishes(ctx, req.(*BatchGetDishesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// DishV1_ServiceDesc is the grpc.ServiceDesc for DishV1 service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var DishV1_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "dish_v1.DishV1",
-	HandlerType: (*DishV1Server)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Create",
-			Handler:    _DishV1_Create_Handler,
-		},
-		{
+func _DishV1_BatchDeleteDishes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteDishesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).BatchDeleteDishes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/BatchDeleteDishes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).BatchDeleteDishes(ctx, req.(*BatchDeleteDishesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreatePerson_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePersonReqest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreatePerson(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreatePerson",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreatePerson(ctx, req.(*CreatePersonReqest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_LogInPerson_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogInPersonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).LogInPerson(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/LogInPerson",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).LogInPerson(ctx, req.(*LogInPersonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ChangePersonPosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePersonPositionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ChangePersonPosition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ChangePersonPosition",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ChangePersonPosition(ctx, req.(*ChangePersonPositionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_AddFavourite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddFavouriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).AddFavourite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/AddFavourite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).AddFavourite(ctx, req.(*AddFavouriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_RemoveFavourite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveFavouriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).RemoveFavourite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/RemoveFavourite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).RemoveFavourite(ctx, req.(*RemoveFavouriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListFavourites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFavouritesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListFavourites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListFavourites",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListFavourites(ctx, req.(*ListFavouritesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreateCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreateCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreateCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreateCollection(ctx, req.(*CreateCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_DeleteCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).DeleteCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/DeleteCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).DeleteCollection(ctx, req.(*DeleteCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListCollections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCollectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListCollections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListCollections",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListCollections(ctx, req.(*ListCollectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_AddDishToCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddDishToCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).AddDishToCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/AddDishToCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).AddDishToCollection(ctx, req.(*AddDishToCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_RemoveDishFromCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveDishFromCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).RemoveDishFromCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/RemoveDishFromCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).RemoveDishFromCollection(ctx, req.(*RemoveDishFromCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreatePromotion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePromotionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreatePromotion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreatePromotion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreatePromotion(ctx, req.(*CreatePromotionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListPromotions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPromotionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListPromotions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListPromotions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListPromotions(ctx, req.(*ListPromotionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_DeletePromotion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePromotionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).DeletePromotion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/DeletePromotion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).DeletePromotion(ctx, req.(*DeletePromotionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreateOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreateOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreateOrder(ctx, req.(*CreateOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/GetOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreateCombo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateComboRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreateCombo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreateCombo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreateCombo(ctx, req.(*CreateComboRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_GetCombo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetComboRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).GetCombo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/GetCombo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).GetCombo(ctx, req.(*GetComboRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListCombos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCombosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListCombos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListCombos",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListCombos(ctx, req.(*ListCombosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_UpdateCombo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateComboRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).UpdateCombo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/UpdateCombo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).UpdateCombo(ctx, req.(*UpdateComboRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_DeleteCombo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteComboRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).DeleteCombo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/DeleteCombo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).DeleteCombo(ctx, req.(*DeleteComboRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreateModifierGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateModifierGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreateModifierGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreateModifierGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreateModifierGroup(ctx, req.(*CreateModifierGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListModifierGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListModifierGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListModifierGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListModifierGroups",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListModifierGroups(ctx, req.(*ListModifierGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_DeleteModifierGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteModifierGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).DeleteModifierGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/DeleteModifierGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).DeleteModifierGroup(ctx, req.(*DeleteModifierGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListAuditEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_CreateWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).CreateWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/CreateWebhookSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).CreateWebhookSubscription(ctx, req.(*CreateWebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListWebhookSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListWebhookSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListWebhookSubscriptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListWebhookSubscriptions(ctx, req.(*ListWebhookSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_DeleteWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).DeleteWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/DeleteWebhookSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).DeleteWebhookSubscription(ctx, req.(*DeleteWebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ListWebhookDeliveries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DishV1_ReplayWebhookDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayWebhookDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DishV1Server).ReplayWebhookDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dish_v1.DishV1/ReplayWebhookDelivery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DishV1Server).ReplayWebhookDelivery(ctx, req.(*ReplayWebhookDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DishV1_ServiceDesc is the grpc.ServiceDesc for DishV1 service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DishV1_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dish_v1.DishV1",
+	HandlerType: (*DishV1Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _DishV1_Create_Handler,
+		},
+		{
 			MethodName: "Get",
 			Handler:    _DishV1_Get_Handler,
 		},
@@ -340,6 +1332,22 @@ var DishV1_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _DishV1_Delete_Handler,
 		},
+		{
+			MethodName: "RestoreDish",
+			Handler:    _DishV1_RestoreDish_Handler,
+		},
+		{
+			MethodName: "BatchCreateDishes",
+			Handler:    _DishV1_BatchCreateDishes_Handler,
+		},
+		{
+			MethodName: "BatchGetDishes",
+			Handler:    _DishV1_BatchGetDishes_Handler,
+		},
+		{
+			MethodName: "BatchDeleteDishes",
+			Handler:    _DishV1_BatchDeleteDishes_Handler,
+		},
 		{
 			MethodName: "CreatePerson",
 			Handler:    _DishV1_CreatePerson_Handler,
@@ -352,6 +1360,114 @@ var DishV1_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ChangePersonPosition",
 			Handler:    _DishV1_ChangePersonPosition_Handler,
 		},
+		{
+			MethodName: "AddFavourite",
+			Handler:    _DishV1_AddFavourite_Handler,
+		},
+		{
+			MethodName: "RemoveFavourite",
+			Handler:    _DishV1_RemoveFavourite_Handler,
+		},
+		{
+			MethodName: "ListFavourites",
+			Handler:    _DishV1_ListFavourites_Handler,
+		},
+		{
+			MethodName: "CreateCollection",
+			Handler:    _DishV1_CreateCollection_Handler,
+		},
+		{
+			MethodName: "DeleteCollection",
+			Handler:    _DishV1_DeleteCollection_Handler,
+		},
+		{
+			MethodName: "ListCollections",
+			Handler:    _DishV1_ListCollections_Handler,
+		},
+		{
+			MethodName: "AddDishToCollection",
+			Handler:    _DishV1_AddDishToCollection_Handler,
+		},
+		{
+			MethodName: "RemoveDishFromCollection",
+			Handler:    _DishV1_RemoveDishFromCollection_Handler,
+		},
+		{
+			MethodName: "CreatePromotion",
+			Handler:    _DishV1_CreatePromotion_Handler,
+		},
+		{
+			MethodName: "ListPromotions",
+			Handler:    _DishV1_ListPromotions_Handler,
+		},
+		{
+			MethodName: "DeletePromotion",
+			Handler:    _DishV1_DeletePromotion_Handler,
+		},
+		{
+			MethodName: "CreateOrder",
+			Handler:    _DishV1_CreateOrder_Handler,
+		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _DishV1_GetOrder_Handler,
+		},
+		{
+			MethodName: "CreateCombo",
+			Handler:    _DishV1_CreateCombo_Handler,
+		},
+		{
+			MethodName: "GetCombo",
+			Handler:    _DishV1_GetCombo_Handler,
+		},
+		{
+			MethodName: "ListCombos",
+			Handler:    _DishV1_ListCombos_Handler,
+		},
+		{
+			MethodName: "UpdateCombo",
+			Handler:    _DishV1_UpdateCombo_Handler,
+		},
+		{
+			MethodName: "DeleteCombo",
+			Handler:    _DishV1_DeleteCombo_Handler,
+		},
+		{
+			MethodName: "CreateModifierGroup",
+			Handler:    _DishV1_CreateModifierGroup_Handler,
+		},
+		{
+			MethodName: "ListModifierGroups",
+			Handler:    _DishV1_ListModifierGroups_Handler,
+		},
+		{
+			MethodName: "DeleteModifierGroup",
+			Handler:    _DishV1_DeleteModifierGroup_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _DishV1_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "CreateWebhookSubscription",
+			Handler:    _DishV1_CreateWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "ListWebhookSubscriptions",
+			Handler:    _DishV1_ListWebhookSubscriptions_Handler,
+		},
+		{
+			MethodName: "DeleteWebhookSubscription",
+			Handler:    _DishV1_DeleteWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _DishV1_ListWebhookDeliveries_Handler,
+		},
+		{
+			MethodName: "ReplayWebhookDelivery",
+			Handler:    _DishV1_ReplayWebhookDelivery_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dish.proto",
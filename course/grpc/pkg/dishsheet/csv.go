@@ -0,0 +1,63 @@
+package dishsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// utf8BOM makes Excel open exported CSV as UTF-8 rather than in the ANSI code page.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// formulaPrefixes start cells which spreadsheet programs evaluate as formulas. Exported cells
+// starting with one of them are escaped with a leading apostrophe, which imports remove again.
+const formulaPrefixes = "=+-@\t\r"
+
+// readCSV accepts comma and semicolon separated files, Excel writes the latter in locales
+// with a decimal comma.
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	r := csv.NewReader(bytes.NewReader(data))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("malformed CSV: %w", err)
+	}
+	for _, record := range records {
+		for i, value := range record {
+			if len(value) > 1 && value[0] == '\'' && strings.IndexByte(formulaPrefixes, value[1]) >= 0 {
+				record[i] = value[1:]
+			}
+		}
+	}
+	return records, nil
+}
+
+func writeCSV(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(utf8BOM)
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+	for _, record := range records {
+		escaped := make([]string, len(record))
+		for i, value := range record {
+			if value != "" && strings.IndexByte(formulaPrefixes, value[0]) >= 0 {
+				value = "'" + value
+			}
+			escaped[i] = value
+		}
+		if err := w.Write(escaped); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
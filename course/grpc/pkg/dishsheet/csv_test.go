@@ -0,0 +1,53 @@
+package dishsheet
+
+import (
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	dishes := []*desc.DishInfo{
+		{Name: "=HYPERLINK(\"http://evil\")", Description: "+1", Composition: "-2", Category: "@cmd"},
+		{Name: "Tea", Description: "a = b"},
+	}
+	data, err := Write(FormatCSV, dishes)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	records, err := readCSV(data)
+	if err != nil {
+		t.Fatalf("readCSV: %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\r\n")[1:] {
+		for _, cell := range strings.Split(line, ",") {
+			cell = strings.TrimPrefix(cell, `"`)
+			if cell != "" && strings.IndexByte(formulaPrefixes, cell[0]) >= 0 {
+				t.Errorf("cell %q of %q starts a formula", cell, line)
+			}
+		}
+	}
+	if got := records[1][0]; got != dishes[0].GetName() {
+		t.Errorf("name read back as %q, want %q", got, dishes[0].GetName())
+	}
+	if got := records[2][2]; got != "a = b" {
+		t.Errorf("description read back as %q", got)
+	}
+}
+
+func TestCSVEmptyAuthor(t *testing.T) {
+	data, err := Write(FormatCSV, []*desc.DishInfo{{Name: "Tea"}})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(string(data), "Tea,0,,,,,,") {
+		t.Errorf("dish without an author exported as %q", data)
+	}
+	rows, rowErrors, err := Read(FormatCSV, data)
+	if err != nil || len(rowErrors) > 0 {
+		t.Fatalf("Read: %v %v", err, rowErrors)
+	}
+	if rows[0].Info.GetAuthor() != 0 {
+		t.Errorf("empty author read as %d", rows[0].Info.GetAuthor())
+	}
+}
@@ -0,0 +1,219 @@
+// Package dishsheet reads and writes dishes as CSV and XLSX spreadsheets, one dish per row
+// under a header row naming the columns. It is shared by the gateway and dishctl.
+package dishsheet
+
+import (
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Supported formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Columns are the spreadsheet columns in export order. Imports match headers case-insensitively,
+// in any order, and require only name. Allergens are listed in one cell separated by commas,
+// an empty author cell is a dish without an author.
+var Columns = []string{"name", "price", "description", "composition", "author", "photo_url", "category", "allergens"}
+
+// ContentTypes maps the formats to their MIME types.
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Row is a dish read from a spreadsheet. Line is the 1-based spreadsheet row, the header is line 1.
+type Row struct {
+	Line int
+	Info *desc.DishInfo
+}
+
+// RowError is a problem with a cell or a whole row, Column is empty for the latter.
+type RowError struct {
+	Line    int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("row %d, column %s: %s", e.Line, e.Column, e.Message)
+}
+
+// FormatFromName returns the format of a file name by its extension.
+func FormatFromName(name string) (string, error) {
+	format := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	if _, ok := ContentTypes[format]; !ok {
+		return "", fmt.Errorf("unsupported file extension %q, expected .csv or .xlsx", path.Ext(name))
+	}
+	return format, nil
+}
+
+// Read parses a spreadsheet of the given format. Cell problems are returned as row errors
+// next to the rows which parsed, a malformed file is an error.
+func Read(format string, data []byte) ([]Row, []*RowError, error) {
+	var records [][]string
+	var err error
+	switch format {
+	case FormatCSV:
+		records, err = readCSV(data)
+	case FormatXLSX:
+		records, err = readXLSX(data)
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q, expected csv or xlsx", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseRecords(records)
+}
+
+// Write encodes dishes in the given format.
+func Write(format string, dishes []*desc.DishInfo) ([]byte, error) {
+	records := [][]string{Columns}
+	for _, info := range dishes {
+		// Dishes without an author have author 0, their cell is left empty.
+		author := ""
+		if info.GetAuthor() != 0 {
+			author = strconv.Itoa(int(info.GetAuthor()))
+		}
+		records = append(records, []string{
+			info.GetName(),
+			strconv.Itoa(int(info.GetPrice())),
+			info.GetDescription(),
+			info.GetComposition(),
+			author,
+			info.GetPhotoUrl(),
+			info.GetCategory(),
+			strings.Join(info.GetAllergens(), ", "),
+		})
+	}
+	switch format {
+	case FormatCSV:
+		return writeCSV(records)
+	case FormatXLSX:
+		return writeXLSX(records, map[int]bool{1: true, 4: true})
+	}
+	return nil, fmt.Errorf("unsupported format %q, expected csv or xlsx", format)
+}
+
+func parseRecords(records [][]string) ([]Row, []*RowError, error) {
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("spreadsheet is empty, expected a header row")
+	}
+	known := make(map[string]bool, len(Columns))
+	for _, column := range Columns {
+		known[column] = true
+	}
+	header := make([]string, len(records[0]))
+	seen := make(map[string]bool)
+	for i, cell := range records[0] {
+		column := strings.ToLower(strings.TrimSpace(cell))
+		switch {
+		case column == "":
+			continue
+		case !known[column]:
+			return nil, nil, fmt.Errorf("unknown column %q, expected %s", cell, strings.Join(Columns, ", "))
+		case seen[column]:
+			return nil, nil, fmt.Errorf("column %q is repeated", cell)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	if !seen["name"] {
+		return nil, nil, fmt.Errorf("column name is required")
+	}
+
+	var rows []Row
+	var rowErrors []*RowError
+	for i, record := range records[1:] {
+		line := i + 2
+		if isBlank(record) {
+			continue
+		}
+		info := &desc.DishInfo{}
+		var cellErrors []*RowError
+		for j, value := range record {
+			if j >= len(header) || header[j] == "" {
+				if strings.TrimSpace(value) != "" {
+					cellErrors = append(cellErrors, &RowError{Line: line, Message: fmt.Sprintf("value %q is outside of the named columns", value)})
+				}
+				continue
+			}
+			if err := setColumn(info, header[j], value); err != nil {
+				cellErrors = append(cellErrors, &RowError{Line: line, Column: header[j], Message: err.Error()})
+			}
+		}
+		if len(cellErrors) > 0 {
+			rowErrors = append(rowErrors, cellErrors...)
+			continue
+		}
+		rows = append(rows, Row{Line: line, Info: info})
+	}
+	return rows, rowErrors, nil
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func setColumn(info *desc.DishInfo, column, value string) error {
+	value = strings.TrimSpace(value)
+	switch column {
+	case "name":
+		info.Name = value
+	case "price":
+		price, err := parseWhole(value)
+		if err != nil {
+			return err
+		}
+		info.Price = price
+	case "description":
+		info.Description = value
+	case "composition":
+		info.Composition = value
+	case "author":
+		author, err := parseWhole(value)
+		if err != nil {
+			return err
+		}
+		info.Author = author
+	case "photo_url":
+		info.PhotoUrl = value
+	case "category":
+		info.Category = value
+	case "allergens":
+		info.Allergens = nil
+		for _, allergen := range strings.Split(value, ",") {
+			if allergen = strings.TrimSpace(allergen); allergen != "" {
+				info.Allergens = append(info.Allergens, allergen)
+			}
+		}
+	}
+	return nil
+}
+
+// parseWhole accepts whole numbers, spreadsheets often store them as 120.0 or 120,00.
+func parseWhole(value string) (int32, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("%q is not a whole number", value)
+	}
+	return int32(n), nil
+}
@@ -0,0 +1,114 @@
+package dishsheet
+
+import (
+	"context"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Report is the outcome of an import. Created holds the ids of the created dishes in row order,
+// it is empty when a row has a problem or the import was a dry run.
+type Report struct {
+	DryRun  bool        `json:"dry_run"`
+	Rows    int         `json:"rows"`
+	Created []int32     `json:"created"`
+	Errors  []*RowError `json:"errors"`
+}
+
+// maxBatchSize is the largest batch the server accepts, rows are sent in batches of this size.
+const maxBatchSize = 500
+
+// violationPart matches a field violation of a batch item as written by the server, "dishes[3].name: must not be empty".
+var violationPart = regexp.MustCompile(`^dishes\[\d+\]\.(\w+): (.*)$`)
+
+// Import creates the dishes of rows in batches of maxBatchSize. The server first checks every
+// row without writing, and nothing is created when a row, including one in rowErrors, has a
+// problem or when dryRun is set. Every batch is created in one transaction; when creating a batch
+// fails, the dishes of the earlier batches stay created and the failure is reported at the first
+// row of the failed batch.
+func Import(ctx context.Context, client desc.DishV1Client, rows []Row, rowErrors []*RowError, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun, Rows: len(rows) + countLines(rowErrors), Created: []int32{}, Errors: append([]*RowError{}, rowErrors...)}
+	if len(rows) == 0 {
+		return report, nil
+	}
+
+	for start := 0; start < len(rows); start += maxBatchSize {
+		batch := rows[start:min(start+maxBatchSize, len(rows))]
+		checked, err := client.BatchCreateDishes(ctx, &desc.BatchCreateDishesRequest{
+			Dishes:       dishInfos(batch),
+			Mode:         desc.BatchMode_BATCH_MODE_PER_ITEM,
+			ValidateOnly: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i, result := range checked.GetResults() {
+			if result.GetError() != nil && i < len(batch) {
+				report.Errors = append(report.Errors, itemErrors(batch[i].Line, result.GetError())...)
+			}
+		}
+	}
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	for start := 0; start < len(rows); start += maxBatchSize {
+		batch := rows[start:min(start+maxBatchSize, len(rows))]
+		created, err := client.BatchCreateDishes(ctx, &desc.BatchCreateDishesRequest{
+			Dishes: dishInfos(batch),
+			Mode:   desc.BatchMode_BATCH_MODE_ALL_OR_NOTHING,
+		})
+		if err != nil {
+			if start == 0 {
+				return nil, err
+			}
+			report.Errors = append(report.Errors, &RowError{
+				Line:    batch[0].Line,
+				Message: fmt.Sprintf("this and the following rows were not created: %s", status.Convert(err).Message()),
+			})
+			return report, nil
+		}
+		for _, result := range created.GetResults() {
+			report.Created = append(report.Created, result.GetId())
+		}
+	}
+	return report, nil
+}
+
+func dishInfos(rows []Row) []*desc.DishInfo {
+	dishes := make([]*desc.DishInfo, 0, len(rows))
+	for _, row := range rows {
+		dishes = append(dishes, row.Info)
+	}
+	return dishes
+}
+
+// itemErrors splits the error of a batch item into the errors of its cells where it can.
+func itemErrors(line int, itemError *desc.BatchItemError) []*RowError {
+	if codes.Code(itemError.GetCode()) != codes.InvalidArgument {
+		return []*RowError{{Line: line, Message: itemError.GetMessage()}}
+	}
+	var errs []*RowError
+	for _, part := range strings.Split(itemError.GetMessage(), "; ") {
+		if m := violationPart.FindStringSubmatch(part); m != nil {
+			errs = append(errs, &RowError{Line: line, Column: m[1], Message: m[2]})
+		} else {
+			errs = append(errs, &RowError{Line: line, Message: part})
+		}
+	}
+	return errs
+}
+
+func countLines(rowErrors []*RowError) int {
+	lines := make(map[int]bool)
+	for _, e := range rowErrors {
+		lines[e.Line] = true
+	}
+	return len(lines)
+}
@@ -0,0 +1,107 @@
+package dishsheet
+
+import (
+	"context"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"testing"
+)
+
+// batchClient is a local stand-in for the dish service, it records the batches it is sent and
+// fails the creation of the batch numbered failCreate (1-based).
+type batchClient struct {
+	desc.DishV1Client
+	failCreate int
+	checked    []int
+	created    []int
+	nextId     int32
+}
+
+func (c *batchClient) BatchCreateDishes(ctx context.Context, in *desc.BatchCreateDishesRequest, opts ...grpc.CallOption) (*desc.BatchCreateDishesResponse, error) {
+	if len(in.GetDishes()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "dishes: must have at most %d items", maxBatchSize)
+	}
+	res := &desc.BatchCreateDishesResponse{}
+	if in.GetValidateOnly() {
+		c.checked = append(c.checked, len(in.GetDishes()))
+		for _, dish := range in.GetDishes() {
+			result := &desc.BatchItemResult{}
+			if dish.GetName() == "" {
+				result.Error = &desc.BatchItemError{Code: int32(codes.InvalidArgument), Message: "dishes[0].name: must not be empty"}
+			}
+			res.Results = append(res.Results, result)
+		}
+		return res, nil
+	}
+	c.created = append(c.created, len(in.GetDishes()))
+	if len(c.created) == c.failCreate {
+		return nil, status.Error(codes.Unavailable, "database is down")
+	}
+	for range in.GetDishes() {
+		c.nextId++
+		res.Results = append(res.Results, &desc.BatchItemResult{Id: c.nextId})
+	}
+	return res, nil
+}
+
+func importRows(n int) []Row {
+	rows := make([]Row, n)
+	for i := range rows {
+		rows[i] = Row{Line: i + 2, Info: &desc.DishInfo{Name: "dish " + strconv.Itoa(i)}}
+	}
+	return rows
+}
+
+func TestImportSplitsBatches(t *testing.T) {
+	client := &batchClient{}
+	report, err := Import(context.Background(), client, importRows(1201), nil, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(report.Errors) > 0 || len(report.Created) != 1201 {
+		t.Fatalf("report: %d created, errors %v", len(report.Created), report.Errors)
+	}
+	want := []int{500, 500, 201}
+	for name, got := range map[string][]int{"checked": client.checked, "created": client.created} {
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+			t.Errorf("%s batches %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestImportChecksEveryBatchBeforeCreating(t *testing.T) {
+	client := &batchClient{}
+	rows := importRows(700)
+	rows[650].Info.Name = ""
+	report, err := Import(context.Background(), client, rows, nil, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(client.created) != 0 || len(report.Created) != 0 {
+		t.Errorf("dishes were created although row %d has an error", rows[650].Line)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Line != rows[650].Line || report.Errors[0].Column != "name" {
+		t.Errorf("errors %v", report.Errors)
+	}
+}
+
+func TestImportReportsFailedBatch(t *testing.T) {
+	client := &batchClient{failCreate: 2}
+	rows := importRows(1200)
+	report, err := Import(context.Background(), client, rows, nil, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(report.Created) != maxBatchSize {
+		t.Errorf("%d dishes reported as created, want the first batch of %d", len(report.Created), maxBatchSize)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Line != rows[maxBatchSize].Line {
+		t.Errorf("errors %v, want one at row %d", report.Errors, rows[maxBatchSize].Line)
+	}
+	if len(client.created) != 2 {
+		t.Errorf("batches after the failed one were sent: %v", client.created)
+	}
+}
@@ -0,0 +1,200 @@
+package dishsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XLSX files are read and written with excelize, only the first worksheet is read.
+// Uploads are checked before excelize opens them: every part of the zip must decompress
+// to at most maxPartSize and worksheets may only refer to rows and columns which exist in Excel.
+const (
+	sheetName = "Dishes"
+	// maxPartSize bounds the decompressed size of every part of an uploaded workbook.
+	maxPartSize = 32 << 20
+	// maxWorkbookSize bounds the decompressed size of a whole uploaded workbook.
+	maxWorkbookSize = 64 << 20
+)
+
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("malformed XLSX: %w", err)
+	}
+	var total int64
+	for _, f := range archive.File {
+		size, err := checkPart(f)
+		if err != nil {
+			return nil, fmt.Errorf("malformed XLSX: %s: %w", f.Name, err)
+		}
+		if total += size; total > maxWorkbookSize {
+			return nil, fmt.Errorf("malformed XLSX: the workbook is larger than %d MB uncompressed", maxWorkbookSize>>20)
+		}
+	}
+
+	book, err := excelize.OpenReader(bytes.NewReader(data), excelize.Options{
+		UnzipSizeLimit:    maxWorkbookSize,
+		UnzipXMLSizeLimit: maxPartSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("malformed XLSX: %w", err)
+	}
+	defer book.Close()
+
+	sheet := book.GetSheetName(0)
+	if sheet == "" {
+		return nil, errors.New("malformed XLSX: the workbook has no sheets")
+	}
+	records, err := book.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("malformed XLSX: %w", err)
+	}
+	return records, nil
+}
+
+// checkPart decompresses a part of a workbook, at most maxPartSize bytes of it, and returns its size.
+// Worksheets are also checked for rows and cells outside of the limits of Excel, which excelize
+// would otherwise fill with empty rows or place in the wrong column.
+func checkPart(f *zip.File) (int64, error) {
+	r, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	limited := &io.LimitedReader{R: r, N: maxPartSize + 1}
+	name := strings.ToLower(f.Name)
+	if strings.HasPrefix(name, "xl/worksheets/") && strings.HasSuffix(name, ".xml") {
+		err = checkSheet(limited)
+	}
+	if err == nil {
+		_, err = io.Copy(io.Discard, limited)
+	}
+	if limited.N == 0 {
+		return 0, fmt.Errorf("larger than %d MB uncompressed", maxPartSize>>20)
+	}
+	return maxPartSize + 1 - limited.N, err
+}
+
+// checkSheet checks that row numbers are within 1-1048576 and cell references within A1-XFD1048576.
+func checkSheet(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	row, cells := 0, 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "row":
+			row, cells = row+1, 0
+			if ref, ok := attribute(start, "r"); ok {
+				if row, err = strconv.Atoi(ref); err != nil || row < 1 || row > excelize.TotalRows {
+					return fmt.Errorf("row number %q is not between 1 and %d", ref, excelize.TotalRows)
+				}
+			}
+			if row > excelize.TotalRows {
+				return fmt.Errorf("more than %d rows", excelize.TotalRows)
+			}
+		case "c":
+			if cells++; cells > excelize.MaxColumns {
+				return fmt.Errorf("row %d has more than %d cells", row, excelize.MaxColumns)
+			}
+			if ref, ok := attribute(start, "r"); ok {
+				if err = checkCellReference(ref); err != nil {
+					return fmt.Errorf("cell %q: %w", ref, err)
+				}
+			}
+		}
+	}
+}
+
+// checkCellReference accepts references within A1-XFD1048576. The column name is checked
+// for length first, excelize.ColumnNameToNumber overflows on long ones.
+func checkCellReference(ref string) error {
+	column, row, err := excelize.SplitCellName(ref)
+	if err != nil {
+		return err
+	}
+	if len(column) > 3 {
+		return excelize.ErrColumnNumber
+	}
+	if _, err = excelize.ColumnNameToNumber(column); err != nil {
+		return err
+	}
+	if row < 1 || row > excelize.TotalRows {
+		return excelize.ErrMaxRows
+	}
+	return nil
+}
+
+func attribute(element xml.StartElement, name string) (string, bool) {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// writeXLSX writes records to a single sheet, cells of numeric columns are stored as numbers
+// and empty cells are left out.
+func writeXLSX(records [][]string, numeric map[int]bool) ([]byte, error) {
+	book := excelize.NewFile()
+	defer book.Close()
+	if err := book.SetSheetName(book.GetSheetName(0), sheetName); err != nil {
+		return nil, err
+	}
+
+	stream, err := book.NewStreamWriter(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	for i, record := range records {
+		cells := make([]interface{}, len(record))
+		for j, value := range record {
+			switch {
+			case value == "":
+				cells[j] = nil
+			case i > 0 && numeric[j]:
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %q is not a number", i+1, value)
+				}
+				cells[j] = n
+			default:
+				cells[j] = value
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if err = stream.SetRow(cell, cells); err != nil {
+			return nil, err
+		}
+	}
+	if err = stream.Flush(); err != nil {
+		return nil, err
+	}
+
+	buf, err := book.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,122 @@
+package dishsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// workbook builds a minimal XLSX whose only worksheet has the given sheetData content.
+func workbook(t *testing.T, sheetData string) []byte {
+	t.Helper()
+	const rels = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + rels + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + rels + `">` +
+			`<sheets><sheet name="Dishes" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + rels + `/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/worksheets/sheet1.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`},
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, part.content)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func inline(ref, value string) string {
+	return `<c r="` + ref + `" t="inlineStr"><is><t>` + value + `</t></is></c>`
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := workbook(t, `<row r="1">`+inline("A1", "name")+inline("B1", "price")+`</row>`+
+		`<row r="3">`+inline("A3", "Borscht")+`<c r="B3"><v>250</v></c></row>`)
+	rows, rowErrors, err := Read(FormatXLSX, data)
+	if err != nil || len(rowErrors) > 0 {
+		t.Fatalf("Read: %v %v", err, rowErrors)
+	}
+	if len(rows) != 1 || rows[0].Line != 3 || rows[0].Info.GetName() != "Borscht" || rows[0].Info.GetPrice() != 250 {
+		t.Errorf("rows %+v", rows)
+	}
+}
+
+func TestReadXLSXRejectsOutOfRangeReferences(t *testing.T) {
+	for name, sheetData := range map[string]string{
+		"column overflow":   `<row r="1">` + inline("ZZZZZZZZZZZZZZZ1", "name") + `</row>`,
+		"column past XFD":   `<row r="1">` + inline("XFE1", "name") + `</row>`,
+		"negative row":      `<row r="-5">` + inline("A1", "name") + `</row>`,
+		"zero row":          `<row r="0">` + inline("A1", "name") + `</row>`,
+		"huge row":          `<row r="99999999999999999999">` + inline("A1", "name") + `</row>`,
+		"row past 1048576":  `<row r="1048577">` + inline("A1", "name") + `</row>`,
+		"cell row overflow": `<row r="1">` + inline("A99999999999999999999", "name") + `</row>`,
+	} {
+		_, _, err := Read(FormatXLSX, workbook(t, sheetData))
+		if err == nil || !strings.HasPrefix(err.Error(), "malformed XLSX") {
+			t.Errorf("%s: got %v, want a malformed XLSX error", name, err)
+		}
+	}
+}
+
+func TestReadXLSXLimitsPartSize(t *testing.T) {
+	// Repeated rows compress to a small upload but decompress past maxPartSize.
+	row := `<row>` + inline("A1", "name") + `</row>`
+	data := workbook(t, strings.Repeat(row, maxPartSize/len(row)+1))
+	if len(data) > maxPartSize/10 {
+		t.Fatalf("test workbook is %d bytes, expected it to compress well", len(data))
+	}
+	_, _, err := Read(FormatXLSX, data)
+	if err == nil || !strings.Contains(err.Error(), "uncompressed") {
+		t.Errorf("got %v, want a size error", err)
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	dishes := []*desc.DishInfo{
+		{Name: "Borscht", Price: 250, Author: 3, Category: "soups", Allergens: []string{"celery"}},
+		{Name: "=SUM(A1)", Price: 0, Description: "no author"},
+	}
+	data, err := Write(FormatXLSX, dishes)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	records, err := readXLSX(data)
+	if err != nil {
+		t.Fatalf("readXLSX: %v", err)
+	}
+	if len(records[2]) > 4 && records[2][4] != "" {
+		t.Errorf("author of a dish without an author is %q, want an empty cell", records[2][4])
+	}
+	rows, rowErrors, err := Read(FormatXLSX, data)
+	if err != nil || len(rowErrors) > 0 {
+		t.Fatalf("Read: %v %v", err, rowErrors)
+	}
+	for i, row := range rows {
+		if !reflect.DeepEqual(row.Info.GetAllergens(), dishes[i].GetAllergens()) {
+			row.Info.Allergens = dishes[i].Allergens
+		}
+		if row.Info.String() != dishes[i].String() {
+			t.Errorf("row %d: got %v, want %v", row.Line, row.Info, dishes[i])
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package menuprint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	margin       = 50.0
+	contentWidth = pageWidth - 2*margin
+	footerY      = 28.0
+	// photoHeight is the height of printed photos, their width follows the aspect ratio.
+	photoHeight = 90.0
+	// keepWithNext is the space a heading needs below it to stay on the page.
+	keepWithNext = 60.0
+)
+
+// directive is a parsed line of the PDF template output.
+type directive struct {
+	name string
+	arg  string
+}
+
+var directiveNames = map[string]bool{
+	"title": true, "heading": true, "item": true, "text": true,
+	"note": true, "image": true, "space": true, "page": true,
+}
+
+func parseDirectives(output string) ([]directive, error) {
+	var directives []directive
+	for i, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(strings.TrimLeft(line, " \t"), " ")
+		if !directiveNames[name] {
+			return nil, fmt.Errorf("%s: line %d: unknown layout directive %q", PDFTemplate, i+1, name)
+		}
+		if name == "space" && arg != "" {
+			if _, err := strconv.ParseFloat(arg, 64); err != nil {
+				return nil, fmt.Errorf("%s: line %d: space expects points, got %q", PDFTemplate, i+1, arg)
+			}
+		}
+		directives = append(directives, directive{name: name, arg: strings.TrimSpace(arg)})
+	}
+	return directives, nil
+}
+
+// layout places directives top to bottom, y is the top of the free space on the current page.
+type layout struct {
+	doc    *document
+	photos *photoCache
+	y      float64
+}
+
+func (l *layout) run(directives []directive) {
+	l.newPage()
+	for _, d := range directives {
+		switch d.name {
+		case "title":
+			size := 22.0
+			for _, line := range wrap(l.doc.bold, size, d.arg, contentWidth) {
+				l.ensure(size * 1.3)
+				l.y -= size * 1.3
+				l.doc.text(l.doc.bold, size, margin+(contentWidth-l.doc.bold.width(line, size))/2, l.y+size*0.3, 0, line)
+			}
+			l.y -= 4
+		case "heading":
+			size := 15.0
+			l.ensure(size*1.3 + 16 + keepWithNext)
+			l.y -= 16
+			for _, line := range wrap(l.doc.bold, size, d.arg, contentWidth) {
+				l.y -= size * 1.3
+				l.doc.text(l.doc.bold, size, margin, l.y+size*0.3, 0, line)
+			}
+			l.y -= 3
+			l.doc.line(margin, l.y, margin+contentWidth, l.y, 0.6)
+			l.y -= 6
+		case "item":
+			l.item(d.arg)
+		case "text":
+			l.paragraph(l.doc.regular, 10, 0, d.arg)
+		case "note":
+			l.paragraph(l.doc.regular, 8.5, 0.4, d.arg)
+		case "image":
+			l.image(d.arg)
+		case "space":
+			space := 6.0
+			if d.arg != "" {
+				space, _ = strconv.ParseFloat(d.arg, 64)
+			}
+			l.y -= space
+		case "page":
+			l.newPage()
+		}
+	}
+	l.footers()
+}
+
+func (l *layout) newPage() {
+	l.doc.addPage()
+	l.y = pageHeight - margin
+}
+
+// ensure starts a new page when less than height is left on this one.
+func (l *layout) ensure(height float64) {
+	if l.y-height < margin && l.y < pageHeight-margin {
+		l.newPage()
+	}
+}
+
+func (l *layout) paragraph(f *pdfFont, size, gray float64, text string) {
+	leading := size * 1.3
+	for _, line := range wrap(f, size, text, contentWidth) {
+		l.ensure(leading)
+		l.y -= leading
+		l.doc.text(f, size, margin, l.y+size*0.3, gray, line)
+	}
+}
+
+// item prints the name wrapped to the left of the price, the price is on the first line.
+func (l *layout) item(arg string) {
+	name, price, _ := strings.Cut(arg, "\t")
+	name, price = strings.TrimSpace(name), strings.TrimSpace(price)
+	size := 11.0
+	leading := size * 1.35
+	priceWidth := l.doc.bold.width(price, size)
+	nameWidth := contentWidth - priceWidth - 12
+	l.ensure(leading * 2)
+	for i, line := range wrap(l.doc.bold, size, name, nameWidth) {
+		l.ensure(leading)
+		l.y -= leading
+		l.doc.text(l.doc.bold, size, margin, l.y+size*0.3, 0, line)
+		if i == 0 && price != "" {
+			l.doc.text(l.doc.bold, size, margin+contentWidth-priceWidth, l.y+size*0.3, 0, price)
+		}
+	}
+}
+
+func (l *layout) image(url string) {
+	img := l.photos.get(url)
+	if img == nil {
+		return
+	}
+	height := photoHeight
+	width := height * float64(img.width) / float64(img.height)
+	if width > contentWidth {
+		width, height = contentWidth, contentWidth*float64(img.height)/float64(img.width)
+	}
+	l.ensure(height + 4)
+	l.y -= height + 4
+	l.doc.image(img, margin, l.y, width, height)
+}
+
+// footers numbers the pages once their count is known.
+func (l *layout) footers() {
+	for i, page := range l.doc.pages {
+		footer := fmt.Sprintf("%d / %d", i+1, len(l.doc.pages))
+		size := 8.0
+		x := margin + (contentWidth-l.doc.regular.width(footer, size))/2
+		drawText(page, l.doc.regular, size, x, footerY, 0.5, footer)
+	}
+}
+
+// wrap breaks text into lines no wider than width, words longer than a line are split.
+func wrap(f *pdfFont, size float64, text string, width float64) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if f.width(candidate, size) <= width {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		current = ""
+		for len([]rune(word)) > 1 && f.width(word, size) > width {
+			runes := []rune(word)
+			cut := len(runes) - 1
+			for cut > 1 && f.width(string(runes[:cut]), size) > width {
+				cut--
+			}
+			lines = append(lines, string(runes[:cut]))
+			word = string(runes[cut:])
+		}
+		current = word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,240 @@
+// Package menuprint renders the printed menu of the available dishes, grouped by category,
+// as HTML or PDF. Both are produced from Go templates in-process, the PDF one writes the
+// layout directives described at RenderPDF. It is shared by the gateway and dishctl.
+package menuprint
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/netguard"
+	htmltemplate "html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Supported formats.
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+// ContentTypes maps the formats to their MIME types.
+var ContentTypes = map[string]string{
+	FormatHTML: "text/html; charset=utf-8",
+	FormatPDF:  "application/pdf",
+}
+
+// Template file names, a template directory may override either of them.
+const (
+	HTMLTemplate = "menu.html.tmpl"
+	PDFTemplate  = "menu.pdf.tmpl"
+)
+
+const (
+	DefaultTitle    = "Menu"
+	DefaultCurrency = "RUB"
+	// uncategorized names the category of dishes without one, it is printed last.
+	uncategorized = "Other"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Menu is the data of the templates.
+type Menu struct {
+	Title       string
+	GeneratedAt time.Time
+	Currency    string
+	// Photos tells the templates to print dish photos.
+	Photos     bool
+	Categories []Category
+}
+
+type Category struct {
+	Name  string
+	Items []Item
+}
+
+// Item is a dish on the menu, text fields are on one line with single spaces.
+type Item struct {
+	ID          int32
+	Name        string
+	Description string
+	Composition string
+	Price       int32
+	Allergens   []string
+	PhotoURL    string
+}
+
+// Build groups the dishes which are not deleted by category. Categories and the dishes in them
+// are sorted by name, dishes without a category go last.
+func Build(title string, dishes []*desc.Dish, generatedAt time.Time) *Menu {
+	if title = oneLine(title); title == "" {
+		title = DefaultTitle
+	}
+	menu := &Menu{Title: title, GeneratedAt: generatedAt, Currency: DefaultCurrency}
+
+	byCategory := make(map[string][]Item)
+	for _, dish := range dishes {
+		if dish.GetDeletedAt() != nil {
+			continue
+		}
+		info := dish.GetInfo()
+		item := Item{
+			ID:          dish.GetId(),
+			Name:        oneLine(info.GetName()),
+			Description: oneLine(info.GetDescription()),
+			Composition: oneLine(info.GetComposition()),
+			Price:       info.GetPrice(),
+			PhotoURL:    strings.TrimSpace(info.GetPhotoUrl()),
+		}
+		for _, allergen := range info.GetAllergens() {
+			if allergen = oneLine(allergen); allergen != "" {
+				item.Allergens = append(item.Allergens, allergen)
+			}
+		}
+		category := oneLine(info.GetCategory())
+		if category == "" {
+			category = uncategorized
+		}
+		byCategory[category] = append(byCategory[category], item)
+	}
+
+	for name, items := range byCategory {
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Name != items[j].Name {
+				return items[i].Name < items[j].Name
+			}
+			return items[i].ID < items[j].ID
+		})
+		menu.Categories = append(menu.Categories, Category{Name: name, Items: items})
+	}
+	sort.Slice(menu.Categories, func(i, j int) bool {
+		a, b := menu.Categories[i].Name, menu.Categories[j].Name
+		if (a == uncategorized) != (b == uncategorized) {
+			return b == uncategorized
+		}
+		return a < b
+	})
+	return menu
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var templateFuncs = map[string]interface{}{
+	"join": strings.Join,
+}
+
+// Renderer renders menus with its templates. It is safe for concurrent use.
+type Renderer struct {
+	html *htmltemplate.Template
+	pdf  *texttemplate.Template
+	// Client fetches the photos of PDF menus, by default it refuses private addresses.
+	Client *http.Client
+}
+
+// NewRenderer reads the templates of dir, the built-in ones are used for the files dir lacks
+// or for all of them when dir is empty.
+func NewRenderer(dir string) (*Renderer, error) {
+	htmlSource, err := readTemplate(dir, HTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	pdfSource, err := readTemplate(dir, PDFTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New(HTMLTemplate).Funcs(templateFuncs).Parse(htmlSource)
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := texttemplate.New(PDFTemplate).Funcs(templateFuncs).Parse(pdfSource)
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{html: html, pdf: pdf, Client: netguard.NewClient(photoTimeout)}, nil
+}
+
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	data, err := defaultTemplates.ReadFile("templates/" + name)
+	return string(data), err
+}
+
+// Render renders the menu in the given format.
+func (r *Renderer) Render(ctx context.Context, format string, menu *Menu) ([]byte, error) {
+	switch format {
+	case FormatHTML:
+		return r.RenderHTML(menu)
+	case FormatPDF:
+		return r.RenderPDF(ctx, menu)
+	}
+	return nil, fmt.Errorf("unsupported format %q, expected html or pdf", format)
+}
+
+// RenderHTML executes the HTML template, photos are linked rather than fetched.
+func (r *Renderer) RenderHTML(menu *Menu) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.html.Execute(&buf, menu); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF executes the PDF template and lays out its output on A4 pages. Every line of the
+// output is a directive, blank lines are skipped:
+//
+//	title TEXT          centered large bold line
+//	heading TEXT        category heading, moved to the next page when it would end one
+//	item NAME<TAB>PRICE bold dish name with the price aligned right
+//	text TEXT           wrapped paragraph
+//	note TEXT           small grey wrapped paragraph
+//	image URL           photo, skipped when it can not be fetched or decoded in time
+//	space [POINTS]      vertical space, 6 points by default
+//	page                page break
+func (r *Renderer) RenderPDF(ctx context.Context, menu *Menu) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.pdf.Execute(&buf, menu); err != nil {
+		return nil, err
+	}
+	directives, err := parseDirectives(buf.String())
+	if err != nil {
+		return nil, err
+	}
+	doc, err := newDocument(menu.Title, menu.GeneratedAt)
+	if err != nil {
+		return nil, err
+	}
+	photos := &photoCache{client: r.Client, images: make(map[string]*pdfImage)}
+	var urls []string
+	for _, d := range directives {
+		if d.name == "image" {
+			urls = append(urls, d.arg)
+		}
+	}
+	photos.prefetch(ctx, urls)
+	l := &layout{doc: doc, photos: photos}
+	l.run(directives)
+	return doc.bytes()
+}
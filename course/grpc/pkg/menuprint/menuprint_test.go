@@ -0,0 +1,178 @@
+package menuprint
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func dish(id int32, name, category string) *desc.Dish {
+	return &desc.Dish{Id: id, Info: &desc.DishInfo{Name: name, Category: category}}
+}
+
+func TestBuild(t *testing.T) {
+	deleted := dish(9, "Deleted", "Soups")
+	deleted.DeletedAt = timestamppb.Now()
+	menu := Build("  ", []*desc.Dish{
+		dish(1, "Tea", ""),
+		dish(2, "Borscht", "Soups"),
+		dish(3, "Coffee", "Drinks"),
+		dish(4, "Apple\n  pie", "Desserts"),
+		dish(5, "Borscht", "Soups"),
+		dish(6, "Cake", "  "),
+		deleted,
+	}, time.Now())
+
+	if menu.Title != DefaultTitle {
+		t.Errorf("title %q, want %q", menu.Title, DefaultTitle)
+	}
+	var got []string
+	for _, category := range menu.Categories {
+		var items []string
+		for _, item := range category.Items {
+			items = append(items, fmt.Sprintf("%s#%d", item.Name, item.ID))
+		}
+		got = append(got, category.Name+": "+strings.Join(items, ", "))
+	}
+	want := []string{
+		"Desserts: Apple pie#4",
+		"Drinks: Coffee#3",
+		"Soups: Borscht#2, Borscht#5",
+		uncategorized + ": Cake#6, Tea#1",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("categories\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// photoServer is a local stand-in for the servers of dish photos.
+type photoServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func newPhotoServer(t *testing.T) *photoServer {
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	img.Set(1, 1, color.Black)
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	photo := buf.Bytes()
+	// A GIF header claiming 65535x65535 pixels, decoding it would allocate gigabytes.
+	huge := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+
+	s := &photoServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.inFlight++
+		s.peak = max(s.peak, s.inFlight)
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/photo"):
+			time.Sleep(20 * time.Millisecond)
+			w.Write(photo)
+		case r.URL.Path == "/huge.gif":
+			w.Write(huge)
+		case r.URL.Path == "/slow":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func renderPhotos(t *testing.T, r *Renderer, ctx context.Context, urls ...string) int {
+	t.Helper()
+	var dishes []*desc.Dish
+	for i, u := range urls {
+		d := dish(int32(i+1), "Dish", "Main")
+		d.Info.PhotoUrl = u
+		dishes = append(dishes, d)
+	}
+	menu := Build("Menu", dishes, time.Now())
+	menu.Photos = true
+	data, err := r.Render(ctx, FormatPDF, menu)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatalf("not a PDF: %.20q", data)
+	}
+	return bytes.Count(data, []byte("/Subtype /Image"))
+}
+
+func testRenderer(t *testing.T) *Renderer {
+	r, err := NewRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Client = &http.Client{Timeout: photoTimeout}
+	return r
+}
+
+func TestRenderPDFPhotos(t *testing.T) {
+	s := newPhotoServer(t)
+	r := testRenderer(t)
+
+	if n := renderPhotos(t, r, context.Background(), s.URL+"/photo", s.URL+"/photo", s.URL+"/missing", s.URL+"/huge.gif"); n != 1 {
+		t.Errorf("%d images embedded, want the one good photo once", n)
+	}
+}
+
+func TestRenderPDFBoundsPhotoFetches(t *testing.T) {
+	s := newPhotoServer(t)
+	r := testRenderer(t)
+
+	var urls []string
+	for i := 0; i < 3*maxPhotoFetches; i++ {
+		urls = append(urls, fmt.Sprintf("%s/photo?%d", s.URL, i))
+	}
+	if n := renderPhotos(t, r, context.Background(), urls...); n != len(urls) {
+		t.Errorf("%d images embedded, want %d", n, len(urls))
+	}
+	if s.peak > maxPhotoFetches {
+		t.Errorf("%d photos fetched at once, want at most %d", s.peak, maxPhotoFetches)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if n := renderPhotos(t, r, ctx, s.URL+"/slow", s.URL+"/slow?2", s.URL+"/photo"); n > 1 {
+		t.Errorf("%d images embedded, the slow ones must be skipped", n)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("rendering took %v after the deadline of the caller", elapsed)
+	}
+}
+
+func TestRenderPDFRefusesPrivatePhotos(t *testing.T) {
+	s := newPhotoServer(t)
+	r, err := NewRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := renderPhotos(t, r, context.Background(), s.URL+"/photo"); n != 0 {
+		t.Errorf("photo from a loopback address was embedded")
+	}
+}
@@ -0,0 +1,344 @@
+package menuprint
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4 in points.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+// pdfFont is a TrueType font embedded whole as a CID font with the Identity-H encoding,
+// text is written as glyph ids. Only the widths and the Unicode mapping of used glyphs are written.
+type pdfFont struct {
+	resource string
+	baseName string
+	ttf      []byte
+	font     *sfnt.Font
+	buf      sfnt.Buffer
+	ppem     fixed.Int26_6
+	missing  sfnt.GlyphIndex
+	widths   map[sfnt.GlyphIndex]int
+	runes    map[sfnt.GlyphIndex]rune
+}
+
+func newPDFFont(resource, baseName string, ttf []byte) (*pdfFont, error) {
+	f, err := sfnt.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	pf := &pdfFont{
+		resource: resource,
+		baseName: baseName,
+		ttf:      ttf,
+		font:     f,
+		ppem:     fixed.I(int(f.UnitsPerEm())),
+		widths:   make(map[sfnt.GlyphIndex]int),
+		runes:    make(map[sfnt.GlyphIndex]rune),
+	}
+	if pf.missing, err = f.GlyphIndex(&pf.buf, '?'); err != nil {
+		return nil, err
+	}
+	return pf, nil
+}
+
+// glyph returns the glyph of r and its advance in thousandths of the font size,
+// runes the font lacks are printed as a question mark.
+func (f *pdfFont) glyph(r rune) (sfnt.GlyphIndex, int) {
+	gid, err := f.font.GlyphIndex(&f.buf, r)
+	if err != nil || gid == 0 {
+		gid, r = f.missing, '?'
+	}
+	if width, ok := f.widths[gid]; ok {
+		return gid, width
+	}
+	advance, err := f.font.GlyphAdvance(&f.buf, gid, f.ppem, font.HintingNone)
+	if err != nil {
+		advance = 0
+	}
+	width := int(advance) * 1000 / int(f.ppem)
+	f.widths[gid] = width
+	f.runes[gid] = r
+	return gid, width
+}
+
+// width is the width of s in points at the given size.
+func (f *pdfFont) width(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		_, w := f.glyph(r)
+		total += w
+	}
+	return float64(total) * size / 1000
+}
+
+// encode writes s as a hex string of glyph ids.
+func (f *pdfFont) encode(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		gid, _ := f.glyph(r)
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// pdfImage is a JPEG image XObject.
+type pdfImage struct {
+	resource      string
+	width, height int
+	jpeg          []byte
+}
+
+// document collects the pages of a PDF and writes it once they are laid out.
+type document struct {
+	title       string
+	createdAt   time.Time
+	regular     *pdfFont
+	bold        *pdfFont
+	pages       []*bytes.Buffer
+	pageImages  []map[*pdfImage]bool
+	imageSerial int
+}
+
+func newDocument(title string, createdAt time.Time) (*document, error) {
+	regular, err := newPDFFont("F1", "GoRegular", goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := newPDFFont("F2", "GoBold", gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return &document{title: title, createdAt: createdAt, regular: regular, bold: bold}, nil
+}
+
+func (d *document) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.pageImages = append(d.pageImages, make(map[*pdfImage]bool))
+}
+
+// text draws s with its baseline starting at x, y in the given gray level, 0 is black.
+func (d *document) text(f *pdfFont, size, x, y, gray float64, s string) {
+	drawText(d.pages[len(d.pages)-1], f, size, x, y, gray, s)
+}
+
+func drawText(page io.Writer, f *pdfFont, size, x, y, gray float64, s string) {
+	fmt.Fprintf(page, "%s g BT /%s %s Tf %s %s Td %s Tj ET\n", num(gray), f.resource, num(size), num(x), num(y), f.encode(s))
+}
+
+func (d *document) line(x1, y1, x2, y2, gray float64) {
+	page := d.pages[len(d.pages)-1]
+	fmt.Fprintf(page, "%s G 0.5 w %s %s m %s %s l S\n", num(gray), num(x1), num(y1), num(x2), num(y2))
+}
+
+// image draws img in the box with the lower left corner at x, y.
+func (d *document) image(img *pdfImage, x, y, width, height float64) {
+	page := d.pages[len(d.pages)-1]
+	if img.resource == "" {
+		d.imageSerial++
+		img.resource = fmt.Sprintf("Im%d", d.imageSerial)
+	}
+	d.pageImages[len(d.pageImages)-1][img] = true
+	fmt.Fprintf(page, "q %s 0 0 %s %s %s cm /%s Do Q\n", num(width), num(height), num(x), num(y), img.resource)
+}
+
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// pdfWriter numbers the objects and records their offsets for the cross-reference table.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *pdfWriter) alloc() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *pdfWriter) object(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes data deflated unless filter names the encoding it already has.
+func (w *pdfWriter) stream(id int, dict string, data []byte, filter string) error {
+	if filter == "" {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data, filter = compressed.Bytes(), "FlateDecode"
+	}
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Filter /%s /Length %d >>\nstream\n", id, dict, filter, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (d *document) bytes() ([]byte, error) {
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	catalog, pages, info := w.alloc(), w.alloc(), w.alloc()
+	fonts := make(map[*pdfFont]int)
+	for _, f := range []*pdfFont{d.regular, d.bold} {
+		id, err := d.writeFont(w, f)
+		if err != nil {
+			return nil, err
+		}
+		fonts[f] = id
+	}
+	fontResources := fmt.Sprintf("/%s %d 0 R /%s %d 0 R", d.regular.resource, fonts[d.regular], d.bold.resource, fonts[d.bold])
+
+	images := make(map[*pdfImage]int)
+	var kids []string
+	for i, content := range d.pages {
+		var xobjects []string
+		for img := range d.pageImages[i] {
+			id, ok := images[img]
+			if !ok {
+				id = w.alloc()
+				images[img] = id
+				dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8", img.width, img.height)
+				if err := w.stream(id, dict, img.jpeg, "DCTDecode"); err != nil {
+					return nil, err
+				}
+			}
+			xobjects = append(xobjects, fmt.Sprintf("/%s %d 0 R", img.resource, id))
+		}
+		sort.Strings(xobjects)
+
+		contentID, pageID := w.alloc(), w.alloc()
+		if err := w.stream(contentID, "", content.Bytes(), ""); err != nil {
+			return nil, err
+		}
+		resources := "/Font << " + fontResources + " >>"
+		if len(xobjects) > 0 {
+			resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+		}
+		w.object(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pages, num(pageWidth), num(pageHeight), resources, contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+
+	w.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	w.object(info, fmt.Sprintf("<< /Title %s /Producer (productionSite menuprint) /CreationDate (D:%s) >>",
+		textString(d.title), d.createdAt.UTC().Format("20060102150405Z")))
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, catalog, info, xref)
+	return w.buf.Bytes(), nil
+}
+
+// writeFont writes the Type0 font of f with its descendant font, descriptor, font file
+// and ToUnicode map, and returns the id of the Type0 font.
+func (d *document) writeFont(w *pdfWriter, f *pdfFont) (int, error) {
+	type0, cidFont, descriptor, file, toUnicode := w.alloc(), w.alloc(), w.alloc(), w.alloc(), w.alloc()
+
+	metrics, err := f.font.Metrics(&f.buf, f.ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	bounds, err := f.font.Bounds(&f.buf, f.ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	units := func(v fixed.Int26_6) int { return int(v) * 1000 / int(f.ppem) }
+
+	gids := make([]int, 0, len(f.widths))
+	for gid := range f.widths {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, f.widths[sfnt.GlyphIndex(gid)])
+	}
+
+	w.object(type0, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.baseName, cidFont, toUnicode))
+	w.object(cidFont, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		f.baseName, descriptor, strings.TrimSpace(widths.String())))
+	w.object(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.baseName, units(bounds.Min.X), -units(bounds.Max.Y), units(bounds.Max.X), -units(bounds.Min.Y),
+		units(metrics.Ascent), -units(metrics.Descent), units(metrics.CapHeight), file))
+	if err := w.stream(file, fmt.Sprintf("/Length1 %d", len(f.ttf)), f.ttf, ""); err != nil {
+		return 0, err
+	}
+	if err := w.stream(toUnicode, "", toUnicodeCMap(f, gids), ""); err != nil {
+		return 0, err
+	}
+	return type0, nil
+}
+
+// toUnicodeCMap lets viewers copy and search the text, it maps the used glyphs back to runes.
+func toUnicodeCMap(f *pdfFont, gids []int) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// A bfchar section holds at most 100 mappings.
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", gid)
+			for _, unit := range utf16.Encode([]rune{f.runes[sfnt.GlyphIndex(gid)]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// textString encodes s as a UTF-16 text string for the document information.
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
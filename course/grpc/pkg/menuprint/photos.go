@@ -0,0 +1,155 @@
+package menuprint
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Photos are fetched before the layout, at most maxPhotoFetches at a time and all of them within
+// photosTimeout, so a slow photo server delays a menu by at most that. Photos which are not fetched
+// in time are skipped like broken ones.
+const (
+	photoTimeout    = 10 * time.Second
+	photosTimeout   = 20 * time.Second
+	maxPhotoFetches = 4
+	// maxPhotoSize bounds the download of a photo.
+	maxPhotoSize = 10 << 20
+	// maxPhotoSourcePixels bounds the decoded size of a photo, checked before decoding it.
+	maxPhotoSourcePixels = 30_000_000
+	// maxPhotoPixels is the longest side of an embedded photo, larger ones are scaled down
+	// to keep the PDF small, this is about 300 dpi at the printed size.
+	maxPhotoPixels = 600
+)
+
+// photoCache fetches every photo once per menu, a failed one is remembered as nil.
+type photoCache struct {
+	client *http.Client
+	images map[string]*pdfImage
+}
+
+// prefetch fetches the photos of urls concurrently, it returns when all of them are fetched
+// or ctx is done.
+func (c *photoCache) prefetch(ctx context.Context, urls []string) {
+	ctx, cancel := context.WithTimeout(ctx, photosTimeout)
+	defer cancel()
+
+	var pending []string
+	for _, rawURL := range urls {
+		if _, ok := c.images[rawURL]; !ok {
+			c.images[rawURL] = nil
+			pending = append(pending, rawURL)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxPhotoFetches)
+	for _, rawURL := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				log.Printf("menu photo %s is skipped: %v", rawURL, ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+			img, err := c.fetch(ctx, rawURL)
+			if err != nil {
+				log.Printf("menu photo %s is skipped: %v", rawURL, err)
+				return
+			}
+			mu.Lock()
+			c.images[rawURL] = img
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+// get returns a prefetched photo, nil when it failed.
+func (c *photoCache) get(rawURL string) *pdfImage {
+	return c.images[rawURL]
+}
+
+// fetch downloads a photo and re-encodes it as a JPEG on a white background, which PDF
+// embeds without decoding.
+func (c *photoCache) fetch(ctx context.Context, rawURL string) (*pdfImage, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxPhotoSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPhotoSize {
+		return nil, fmt.Errorf("photo is larger than %d bytes", maxPhotoSize)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("photo is empty")
+	}
+	if int64(config.Width)*int64(config.Height) > maxPhotoSourcePixels {
+		return nil, fmt.Errorf("photo of %dx%d pixels is larger than %d pixels", config.Width, config.Height, maxPhotoSourcePixels)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("photo is empty")
+	}
+	if width > maxPhotoPixels || height > maxPhotoPixels {
+		if width >= height {
+			width, height = maxPhotoPixels, max(1, height*maxPhotoPixels/width)
+		} else {
+			width, height = max(1, width*maxPhotoPixels/height), maxPhotoPixels
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &pdfImage{width: width, height: height, jpeg: buf.Bytes()}, nil
+}
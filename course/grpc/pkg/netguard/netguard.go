@@ -0,0 +1,89 @@
+// Package netguard keeps outgoing HTTP requests to user supplied URLs, such as webhooks and
+// dish photos, away from the private network of the server.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for addresses which are not publicly routable.
+var ErrPrivateAddress = errors.New("address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which net.IP does not classify.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublic reports whether ip is publicly routable: loopback, private, link-local,
+// multicast, unspecified and shared addresses are not.
+func IsPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// CheckURL verifies that rawURL is an http or https URL whose host resolves only to public addresses.
+// The client of NewClient checks the address again when it connects, so a host cannot resolve to a
+// public address here and to a private one later.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err = checkIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkIP(ip net.IP) error {
+	if !IsPublic(ip) {
+		return fmt.Errorf("%s: %w", ip, ErrPrivateAddress)
+	}
+	return nil
+}
+
+// NewClient returns a client which refuses to connect to addresses which are not public,
+// including after redirects. It ignores the proxy environment variables.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+			}
+			return checkIP(ip)
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	for address, want := range map[string]bool{
+		"8.8.8.8":         true,
+		"2a00:1450::1":    true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"224.0.0.1":       false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if got := IsPublic(net.ParseIP(address)); got != want {
+			t.Errorf("IsPublic(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://localhost/hook",
+		"ftp://example.com/hook",
+	} {
+		if err := CheckURL(context.Background(), rawURL); err == nil {
+			t.Errorf("CheckURL(%s) accepted a private target", rawURL)
+		}
+	}
+	if err := CheckURL(context.Background(), "https://93.184.215.14/hook"); err != nil {
+		t.Errorf("CheckURL rejected a public address: %v", err)
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer redirect.Close()
+
+	client := NewClient(time.Second)
+	for _, rawURL := range []string{target.URL, redirect.URL} {
+		_, err := client.Get(rawURL)
+		if !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("GET %s: %v, want %v", rawURL, err, ErrPrivateAddress)
+		}
+	}
+}
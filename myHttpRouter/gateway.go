@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	reservationDesc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/reservation_v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REST routes generated from the google.api.http annotations in dish.proto and reservation.proto live under this prefix.
+const restPrefix = "/v1"
+
+// mergePatchType is the media type of a JSON Merge Patch (RFC 7396). The generated PATCH routes
+// build the update mask from the keys of the body, keys set to null clear their field.
+const mergePatchType = "application/merge-patch+json"
+
+// updateDishRoute is the generated route of Update, requests to it may carry an If-Match header.
+const updateDishRoute = restPrefix + "/dishes/{id}"
+
+// newRESTGateway builds the generated grpc-gateway handler on top of the shared dish and reservation clients.
+func newRESTGateway(ctx context.Context) (http.Handler, error) {
+	marshaler := &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames:   true,
+			EmitUnpopulated: true,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, marshaler),
+		runtime.WithMarshalerOption(mergePatchType, marshaler),
+		runtime.WithIncomingHeaderMatcher(forwardedHeaders),
+		runtime.WithForwardResponseOption(setVersionETag),
+		runtime.WithErrorHandler(gatewayError),
+		runtime.WithRoutingErrorHandler(gatewayRoutingError),
+	)
+	if err := desc.RegisterDishV1HandlerClient(ctx, mux, dishClient); err != nil {
+		return nil, err
+	}
+	if err := reservationDesc.RegisterReservationV1HandlerClient(ctx, mux, reservationClient); err != nil {
+		return nil, err
+	}
+	return withGRPCDeadline(withLocation(mux)), nil
+}
+
+// forwardedHeaders keeps the default header forwarding and adds the authorization and request id headers.
+func forwardedHeaders(key string) (string, bool) {
+	switch http.CanonicalHeaderKey(key) {
+	case authorizationHeader:
+		return authorizationMetadata, true
+	case requestIdHeader:
+		return requestIdMetadata, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// Messages of the generated handlers for path and query parameters they fail to parse.
+var (
+	gatewayTypeMismatch    = regexp.MustCompile(`^type mismatch, parameter: (\w+), error: (.*)$`)
+	gatewayMissingParam    = regexp.MustCompile(`^missing parameter (\w+)$`)
+	gatewayQueryParseError = regexp.MustCompile(`^parsing (?:field|list) "(\w+)": (.*)$`)
+)
+
+// gatewayError writes errors of the generated routes as the hand-written routes do, malformed
+// parameters are reported as a paramError and backend errors go through grpcError.
+func gatewayError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if pe := gatewayParamError(err); pe != nil {
+		writeParamError(w, pe)
+		return
+	}
+	grpcError(w, err, http.StatusText(http.StatusInternalServerError))
+}
+
+// gatewayParamError recognises the parameter errors of the generated handlers, nil for any other error.
+func gatewayParamError(err error) *paramError {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.InvalidArgument {
+		return nil
+	}
+	if m := gatewayMissingParam.FindStringSubmatch(st.Message()); m != nil {
+		return &paramError{Name: m[1], Reason: "is required"}
+	}
+	m := gatewayTypeMismatch.FindStringSubmatch(st.Message())
+	if m == nil {
+		m = gatewayQueryParseError.FindStringSubmatch(st.Message())
+	}
+	if m == nil {
+		return nil
+	}
+	reason := "is malformed"
+	switch {
+	case strings.HasSuffix(m[2], strconv.ErrRange.Error()):
+		reason = fmt.Sprintf("must be between %d and %d", math.MinInt32, math.MaxInt32)
+	case strings.HasPrefix(m[2], "strconv.ParseInt") || strings.HasPrefix(m[2], "strconv.ParseUint"):
+		reason = "must be an integer"
+	case strings.HasPrefix(m[2], "strconv.ParseBool"):
+		reason = "must be true or false"
+	}
+	return &paramError{Name: m[1], Reason: reason}
+}
+
+// gatewayRoutingError answers requests matching no generated route with the JSON error of the hand-written routes.
+func gatewayRoutingError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	writeJSONError(w, httpStatus, ErrorResponse{Error: http.StatusText(httpStatus)})
+}
+
+// withGRPCDeadline applies the same per-request deadline as the hand-written handlers.
+func withGRPCDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := grpcContext(r)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// setVersionETag sets the ETag of a dish returned by Get, clients send it back as If-Match when updating the dish.
+func setVersionETag(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+	if res, ok := resp.(*desc.GetResponse); ok {
+		w.Header().Set("ETag", versionETag(res.GetNote().GetVersion()))
+	}
+	return nil
+}
+
+// withIfMatch passes the version of an If-Match header to Update as its expected_version.
+func withIfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+		if version != nil {
+			query := r.URL.Query()
+			query.Set("expected_version", strconv.Itoa(int(version.GetValue())))
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timestampFields are the JSON names of the timestamp fields of the REST API.
+var timestampFields = timestampFieldNames(desc.File_dish_proto, reservationDesc.File_reservation_proto)
+
+func timestampFieldNames(files ...protoreflect.FileDescriptor) map[string]bool {
+	names := make(map[string]bool)
+	var add func(messages protoreflect.MessageDescriptors)
+	add = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			fields := messages.Get(i).Fields()
+			for j := 0; j < fields.Len(); j++ {
+				if message := fields.Get(j).Message(); message != nil && message.FullName() == "google.protobuf.Timestamp" {
+					names[string(fields.Get(j).Name())] = true
+				}
+			}
+			add(messages.Get(i).Messages())
+		}
+	}
+	for _, file := range files {
+		add(file.Messages())
+	}
+	return names
+}
+
+// withLocation shows the timestamps of a successful response in the time zone of the tz query parameter.
+func withLocation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("tz") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		loc, ok := queryParamLocation(w, r)
+		if !ok {
+			return
+		}
+		query := r.URL.Query()
+		query.Del("tz")
+		r.URL.RawQuery = query.Encode()
+
+		buffered := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(buffered, r)
+		body := buffered.body.Bytes()
+		if buffered.status >= 200 && buffered.status < 300 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			if localized, err := localizeTimestamps(body, loc); err == nil {
+				body = localized
+			}
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(buffered.status)
+		w.Write(body)
+	})
+}
+
+// bufferedResponse keeps the status and the body of a response, headers go to the wrapped writer.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// localizeTimestamps rewrites the values of timestampFields in a JSON document to the time zone loc.
+func localizeTimestamps(data []byte, loc *time.Location) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	var localize func(value interface{})
+	localize = func(value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, v := range value {
+				if s, ok := v.(string); ok && timestampFields[key] {
+					if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+						value[key] = t.In(loc).Format(time.RFC3339Nano)
+					}
+					continue
+				}
+				localize(v)
+			}
+		case []interface{}:
+			for _, v := range value {
+				localize(v)
+			}
+		}
+	}
+	localize(document)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
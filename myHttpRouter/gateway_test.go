@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	reservationDesc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/reservation_v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubDishClient answers Get with a fixed dish and records the last Update request.
+type stubDishClient struct {
+	desc.DishV1Client
+	update *desc.UpdateRequest
+}
+
+func (c *stubDishClient) Get(ctx context.Context, in *desc.GetRequest, opts ...grpc.CallOption) (*desc.GetResponse, error) {
+	createdAt := time.Date(2026, 3, 1, 21, 30, 0, 0, time.UTC)
+	return &desc.GetResponse{Note: &desc.Dish{
+		Id:        in.GetId(),
+		Info:      &desc.DishInfo{Name: "Borscht", Description: "2026-03-01T21:30:00Z"},
+		CreatedAt: timestamppb.New(createdAt),
+		UpdatedAt: timestamppb.New(createdAt.Add(time.Hour)),
+		Version:   3,
+	}}, nil
+}
+
+func (c *stubDishClient) Update(ctx context.Context, in *desc.UpdateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.update = in
+	return &emptypb.Empty{}, nil
+}
+
+func newTestRouter(t *testing.T) (http.Handler, *stubDishClient) {
+	t.Helper()
+	client := &stubDishClient{}
+	dishClient = client
+	gateway, err := newRESTGateway(context.Background())
+	if err != nil {
+		t.Fatalf("newRESTGateway: %v", err)
+	}
+	r, err := newRouter(gateway)
+	if err != nil {
+		t.Fatalf("newRouter: %v", err)
+	}
+	return r, client
+}
+
+func serve(r http.Handler, method, target string, header map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGatewayLocation(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		target    string
+		status    int
+		createdAt string
+	}{
+		{"/v1/dishes/7", http.StatusOK, "2026-03-01T21:30:00Z"},
+		{"/v1/dishes/7?tz=Europe/Moscow", http.StatusOK, "2026-03-02T00:30:00+03:00"},
+		{"/v1/dishes/7?tz=UTC", http.StatusOK, "2026-03-01T21:30:00Z"},
+		{"/v1/dishes/7?tz=Mars/Olympus", http.StatusBadRequest, ""},
+		{"/v1/dishes/7?tz=Local", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		rec := serve(r, http.MethodGet, tt.target, nil, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s: status %d, want %d: %s", tt.target, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			var response ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || len(response.FieldViolations) != 1 || response.FieldViolations[0].Field != "tz" {
+				t.Errorf("GET %s: body %s, want a violation of tz", tt.target, rec.Body)
+			}
+			continue
+		}
+		var res struct {
+			Note struct {
+				CreatedAt string `json:"created_at"`
+				Info      struct {
+					Description string `json:"description"`
+				} `json:"info"`
+			} `json:"note"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("GET %s: %v", tt.target, err)
+		}
+		if res.Note.CreatedAt != tt.createdAt {
+			t.Errorf("GET %s: created_at %q, want %q", tt.target, res.Note.CreatedAt, tt.createdAt)
+		}
+		if res.Note.Info.Description != "2026-03-01T21:30:00Z" {
+			t.Errorf("GET %s: description rewritten to %q", tt.target, res.Note.Info.Description)
+		}
+		if etag := rec.Header().Get("ETag"); etag != `"3"` {
+			t.Errorf("GET %s: ETag %q, want %q", tt.target, etag, `"3"`)
+		}
+	}
+}
+
+func TestGatewayUpdate(t *testing.T) {
+	r, client := newTestRouter(t)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		body    string
+		status  int
+		version int32
+		mask    string
+	}{
+		{"merge patch", "", `{"name": "Soup", "price": null}`, http.StatusOK, 0, "name,price"},
+		{"if-match", `"3"`, `{"name": "Soup"}`, http.StatusOK, 3, "name"},
+		{"weak if-match", `W/"4"`, `{"category": "Soups"}`, http.StatusOK, 4, "category"},
+		{"any version", "*", `{"name": "Soup"}`, http.StatusOK, 0, "name"},
+		{"malformed if-match", "3", `{"name": "Soup"}`, http.StatusBadRequest, 0, ""},
+		{"zero version", `"0"`, `{"name": "Soup"}`, http.StatusBadRequest, 0, ""},
+		{"unknown field", "", `{"colour": "red"}`, http.StatusBadRequest, 0, ""},
+	}
+	for _, tt := range tests {
+		client.update = nil
+		header := map[string]string{"Content-Type": mergePatchType}
+		if tt.ifMatch != "" {
+			header["If-Match"] = tt.ifMatch
+		}
+		rec := serve(r, http.MethodPatch, "/v1/dishes/7", header, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			if client.update != nil {
+				t.Errorf("%s: rejected update reached the backend", tt.name)
+			}
+			continue
+		}
+		if got := client.update.GetExpectedVersion(); (got == nil) != (tt.version == 0) || got.GetValue() != tt.version {
+			t.Errorf("%s: expected_version %v, want %d", tt.name, got, tt.version)
+		}
+		if mask := strings.Join(client.update.GetUpdateMask().GetPaths(), ","); mask != tt.mask {
+			t.Errorf("%s: update_mask %q, want %q", tt.name, mask, tt.mask)
+		}
+		if client.update.GetId() != 7 {
+			t.Errorf("%s: id %d, want 7", tt.name, client.update.GetId())
+		}
+	}
+	rec := serve(r, http.MethodPatch, "/v1/dishes/7", map[string]string{"Content-Type": mergePatchType}, `{"name": "Soup", "allergens": ["milk"]}`)
+	if dish := client.update.GetDish(); rec.Code != http.StatusOK || dish.GetName() != "Soup" || len(dish.GetAllergens()) != 1 {
+		t.Errorf("merge patch: status %d, dish %v", rec.Code, dish)
+	}
+}
+
+func TestGatewayErrors(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		want   string
+	}{
+		{"malformed id", http.MethodGet, "/v1/dishes/borscht", http.StatusBadRequest,
+			`{"error":"invalid parameter","field_violations":[{"field":"id","description":"must be an integer"}]}`},
+		{"id out of range", http.MethodGet, "/v1/dishes/4294967296", http.StatusBadRequest,
+			`{"error":"invalid parameter","field_violations":[{"field":"id","description":"must be between -2147483648 and 2147483647"}]}`},
+		{"malformed query parameter", http.MethodGet, "/v1/dishes?show_deleted=maybe", http.StatusBadRequest,
+			`{"error":"invalid parameter","field_violations":[{"field":"show_deleted","description":"must be true or false"}]}`},
+		{"unknown route", http.MethodGet, "/v1/drinks", http.StatusNotFound, `{"error":"Not Found"}`},
+	}
+	for _, tt := range tests {
+		rec := serve(r, tt.method, tt.target, nil, "")
+		if rec.Code != tt.status || strings.TrimSpace(rec.Body.String()) != tt.want {
+			t.Errorf("%s: %d %s, want %d %s", tt.name, rec.Code, rec.Body, tt.status, tt.want)
+		}
+	}
+}
+
+// stubReservationClient answers ListReservations with a single reservation of the requested table.
+type stubReservationClient struct {
+	reservationDesc.ReservationV1Client
+}
+
+func (c *stubReservationClient) ListReservations(ctx context.Context, in *reservationDesc.ListReservationsRequest, opts ...grpc.CallOption) (*reservationDesc.ListReservationsResponse, error) {
+	startsAt := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	return &reservationDesc.ListReservationsResponse{Reservations: []*reservationDesc.Reservation{{
+		Id: 1,
+		Info: &reservationDesc.ReservationInfo{
+			TableId:  in.GetTableId(),
+			Guests:   2,
+			StartsAt: timestamppb.New(startsAt),
+			EndsAt:   timestamppb.New(startsAt.Add(2 * time.Hour)),
+		},
+		CreatedAt: timestamppb.New(startsAt.Add(-24 * time.Hour)),
+	}}}, nil
+}
+
+func TestGatewayReservations(t *testing.T) {
+	reservationClient = &stubReservationClient{}
+	r, _ := newTestRouter(t)
+
+	rec := serve(r, http.MethodGet, "/v1/reservations?date=2026-03-01&table_id=4&tz=Europe/Moscow", nil, "")
+	var res struct {
+		Reservations []struct {
+			Info struct {
+				TableId  int32  `json:"table_id"`
+				StartsAt string `json:"starts_at"`
+				EndsAt   string `json:"ends_at"`
+			} `json:"info"`
+		} `json:"reservations"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusOK || err != nil || len(res.Reservations) != 1 {
+		t.Fatalf("list reservations: %d %s", rec.Code, rec.Body)
+	}
+	if info := res.Reservations[0].Info; info.TableId != 4 || info.StartsAt != "2026-03-01T21:00:00+03:00" || info.EndsAt != "2026-03-01T23:00:00+03:00" {
+		t.Errorf("list reservations: %+v", info)
+	}
+
+	rec = serve(r, http.MethodDelete, "/v1/tables/window", nil, "")
+	want := `{"error":"invalid parameter","field_violations":[{"field":"id","description":"must be an integer"}]}`
+	if rec.Code != http.StatusBadRequest || strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("delete table with a malformed id: %d %s", rec.Code, rec.Body)
+	}
+}
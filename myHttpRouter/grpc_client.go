@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	reservationDesc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/reservation_v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	grpcRequestTimeout = 5 * time.Second
+	grpcKeepaliveTime  = 30 * time.Second
+	grpcKeepaliveWait  = 10 * time.Second
+)
+
+// Clients are created once at startup and share a single connection to grpc_server.
+var (
+	grpcConn          *grpc.ClientConn
+	dishClient        desc.DishV1Client
+	reservationClient reservationDesc.ReservationV1Client
+)
+
+// connectGRPC opens the shared connection. Dial does not block: if the backend
+// is down the connection keeps reconnecting with backoff and calls fail with Unavailable.
+func connectGRPC() error {
+	conn, err := grpc.Dial(grpcUrl,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                grpcKeepaliveTime,
+			Timeout:             grpcKeepaliveWait,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  time.Second,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   30 * time.Second,
+			},
+			MinConnectTimeout: 5 * time.Second,
+		}),
+	)
+	if err != nil {
+		log.Printf("failed to connect to server %v", err)
+		return err
+	}
+	grpcConn = conn
+	dishClient = desc.NewDishV1Client(conn)
+	reservationClient = reservationDesc.NewReservationV1Client(conn)
+	return nil
+}
+
+// authorizationHeader carries the bearer token returned by POST /v1/persons/login and requestIdHeader
+// identifies the request in the audit log, both are forwarded to the backend as metadata.
+const (
+	authorizationHeader   = "Authorization"
+	authorizationMetadata = "authorization"
+	requestIdHeader       = "X-Request-Id"
+	requestIdMetadata     = "x-request-id"
+)
+
+// grpcContext derives the deadline of a backend call from the HTTP request,
+// so a call is cancelled as soon as the HTTP client goes away.
+func grpcContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx := r.Context()
+	if authorization := r.Header.Get(authorizationHeader); authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationMetadata, authorization)
+	}
+	if requestId := r.Header.Get(requestIdHeader); requestId != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIdMetadata, requestId)
+	}
+	return context.WithTimeout(ctx, grpcRequestTimeout)
+}
+
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+type ErrorResponse struct {
+	Error           string           `json:"error"`
+	FieldViolations []FieldViolation `json:"field_violations,omitempty"`
+}
+
+func writeJSONError(w http.ResponseWriter, code int, response ErrorResponse) {
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// grpcError writes the HTTP response for a failed backend call.
+func grpcError(w http.ResponseWriter, err error, message string) {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.InvalidArgument:
+		response := ErrorResponse{Error: st.Message()}
+		for _, detail := range st.Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				for _, v := range badRequest.GetFieldViolations() {
+					response.FieldViolations = append(response.FieldViolations, FieldViolation{
+						Field:       v.GetField(),
+						Description: v.GetDescription(),
+					})
+				}
+			}
+		}
+		writeJSONError(w, http.StatusBadRequest, response)
+	case codes.Unauthenticated:
+		writeJSONError(w, http.StatusUnauthorized, ErrorResponse{Error: st.Message()})
+	case codes.PermissionDenied:
+		writeJSONError(w, http.StatusForbidden, ErrorResponse{Error: st.Message()})
+	case codes.NotFound:
+		writeJSONError(w, http.StatusNotFound, ErrorResponse{Error: st.Message()})
+	case codes.Aborted, codes.AlreadyExists, codes.FailedPrecondition:
+		writeJSONError(w, http.StatusConflict, ErrorResponse{Error: st.Message()})
+	case codes.Unavailable:
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+	case codes.DeadlineExceeded:
+		http.Error(w, "Service timeout", http.StatusGatewayTimeout)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
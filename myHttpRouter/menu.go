@@ -0,0 +1,66 @@
+package main
+
+import (
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/menuprint"
+	"net/http"
+	"os"
+	"time"
+)
+
+const printMenu = "/menu/print"
+
+// menuTemplateDirEnv names a directory with menu.html.tmpl and menu.pdf.tmpl overriding the built-in templates.
+const menuTemplateDirEnv = "MENU_TEMPLATE_DIR"
+
+var menuRenderer *menuprint.Renderer
+
+func loadMenuRenderer() error {
+	renderer, err := menuprint.NewRenderer(os.Getenv(menuTemplateDirEnv))
+	if err != nil {
+		return err
+	}
+	menuRenderer = renderer
+	return nil
+}
+
+// printMenuHandler renders the dishes which are not deleted for printing. Photos of a PDF are
+// fetched by the gateway within their own deadline, so rendering is not bound by the gRPC timeout.
+func printMenuHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = menuprint.FormatPDF
+	}
+	if _, ok := menuprint.ContentTypes[format]; !ok {
+		writeParamError(w, &paramError{Name: "format", Reason: "must be pdf or html"})
+		return
+	}
+	photos, ok := queryParamBool(w, r, "photos")
+	if !ok {
+		return
+	}
+	loc, ok := queryParamLocation(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := grpcContext(r)
+	defer cancel()
+
+	grpcRes, err := dishClient.List(ctx, &desc.ListRequest{})
+	if err != nil {
+		grpcError(w, err, "Failed to list dishes")
+		return
+	}
+	menu := menuprint.Build(r.URL.Query().Get("title"), grpcRes.GetDishes(), time.Now().In(loc))
+	menu.Photos = photos
+	data, err := menuRenderer.Render(r.Context(), format, menu)
+	if err != nil {
+		http.Error(w, "Failed to render menu", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", menuprint.ContentTypes[format])
+	w.Header().Set("Content-Disposition", `inline; filename="menu.`+format+`"`)
+	w.Write(data)
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"encoding/json"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dishsheet"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/menuprint"
+	reservationDesc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/reservation_v1"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	openAPIDocument = "/openapi.json"
+	swaggerUI       = "/docs"
+)
+
+type routeDoc struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	Query   []paramDoc
+	// Status is the status of a successful response.
+	Status int
+	// Request and Response are values of the Go types of JSON bodies, nil when there is no JSON body.
+	Request  interface{}
+	Response interface{}
+	// RequestTypes and ResponseTypes are media types of bodies which are not JSON.
+	RequestTypes  []string
+	ResponseTypes []string
+}
+
+type paramDoc struct {
+	Name        string
+	Type        string
+	Format      string
+	Required    bool
+	Description string
+}
+
+var (
+	spreadsheetTypes = []string{dishsheet.ContentTypes[dishsheet.FormatCSV], dishsheet.ContentTypes[dishsheet.FormatXLSX]}
+	formatParam      = paramDoc{Name: "format", Type: "string", Description: "csv or xlsx, defaults to the Content-Type of the body or csv"}
+	tzParam          = paramDoc{Name: "tz", Type: "string", Description: "IANA time zone of the returned times, defaults to UTC"}
+	ifMatchParam     = paramDoc{Name: "If-Match", Type: "string", Description: "ETag returned by GET, the update fails with 409 when the dish has changed since"}
+)
+
+// gatewayParams are applied by the middleware around the generated gateway, they are not fields of the RPC requests.
+var gatewayParams = []paramDoc{tzParam, ifMatchParam}
+
+// handlerRoutes documents the hand-written chi routes, which are not RPCs of gatewayServices. Every route registered
+// in main must be listed here or in legacyRoutes, openapi_test.go fails otherwise.
+var handlerRoutes = []routeDoc{
+	{
+		Method: http.MethodPost, Path: importDishes, Tag: "dishes", Status: http.StatusCreated,
+		Summary:      "Import dishes from a CSV or XLSX spreadsheet, optionally as a dry run",
+		Query:        []paramDoc{formatParam, {Name: "dry_run", Type: "boolean"}},
+		RequestTypes: spreadsheetTypes,
+		Response:     dishsheet.Report{},
+	},
+	{
+		Method: http.MethodGet, Path: exportDishes, Tag: "dishes", Status: http.StatusOK,
+		Summary:       "Export dishes as a CSV or XLSX spreadsheet",
+		Query:         []paramDoc{formatParam},
+		ResponseTypes: spreadsheetTypes,
+	},
+	{
+		Method: http.MethodGet, Path: printMenu, Tag: "dishes", Status: http.StatusOK,
+		Summary: "Render the menu of available dishes as PDF or HTML for printing",
+		Query: []paramDoc{
+			{Name: "format", Type: "string", Description: "pdf or html, defaults to pdf"},
+			{Name: "photos", Type: "boolean"},
+			{Name: "title", Type: "string"},
+			tzParam,
+		},
+		ResponseTypes: []string{menuprint.ContentTypes[menuprint.FormatPDF], menuprint.ContentTypes[menuprint.FormatHTML]},
+	},
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// gatewayServices are the services served by the generated gateway.
+var gatewayServices = []protoreflect.ServiceDescriptor{
+	desc.File_dish_proto.Services().ByName("DishV1"),
+	reservationDesc.File_reservation_proto.Services().ByName("ReservationV1"),
+}
+
+// buildOpenAPI generates an OpenAPI 3 document from the google.api.http annotations of gatewayServices
+// and from the documented hand-written routes.
+func buildOpenAPI() map[string]interface{} {
+	paths := make(map[string]map[string]interface{})
+	schemas := make(map[string]interface{})
+	addOperation := func(path, method string, op map[string]interface{}) {
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(method)] = op
+	}
+
+	for _, service := range gatewayServices {
+		methods := service.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			method := methods.Get(i)
+			rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			verb, path := httpRulePattern(rule)
+			if verb == "" {
+				continue
+			}
+			input := method.Input()
+			op := map[string]interface{}{
+				"operationId": string(service.Name()) + "_" + string(method.Name()),
+				"tags":        []string{string(service.Name())},
+				"responses": map[string]interface{}{
+					"200":     jsonContent("OK", messageSchema(method.Output(), schemas)),
+					"default": jsonContent("Error", goSchema(reflect.TypeOf(ErrorResponse{}), schemas)),
+				},
+			}
+
+			params := make([]interface{}, 0)
+			inPath := make(map[string]bool)
+			for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+				inPath[m[1]] = true
+				field := input.Fields().ByName(protoreflect.Name(m[1]))
+				params = append(params, map[string]interface{}{
+					"name":     m[1],
+					"in":       "path",
+					"required": true,
+					"schema":   fieldSchema(field, schemas),
+				})
+			}
+			switch rule.GetBody() {
+			case "":
+				params = append(params, queryParams(input, "", inPath, schemas)...)
+			case "*":
+				op["requestBody"] = requestBody(messageSchema(input, schemas))
+			default:
+				field := input.Fields().ByName(protoreflect.Name(rule.GetBody()))
+				body := requestBody(fieldSchema(field, schemas))
+				if verb == http.MethodPatch && input.Fields().ByName("update_mask") != nil {
+					// Without update_mask the gateway builds it from the keys of the body.
+					content := body["content"].(map[string]interface{})
+					content[mergePatchType] = content["application/json"]
+				}
+				op["requestBody"] = body
+				inPath[rule.GetBody()] = true
+				params = append(params, queryParams(input, "", inPath, schemas)...)
+			}
+			if hasTimestamps(method.Output(), make(map[protoreflect.FullName]bool)) {
+				params = append(params, paramSchema(tzParam, "query"))
+			}
+			if verb+" "+path == http.MethodPatch+" "+updateDishRoute {
+				params = append(params, paramSchema(ifMatchParam, "header"))
+			}
+			op["parameters"] = params
+			addOperation(path, verb, op)
+		}
+	}
+
+	for _, route := range handlerRoutes {
+		addOperation(route.Path, route.Method, handlerOperation(route, schemas))
+	}
+	for _, route := range legacyRoutes {
+		params := make([]interface{}, 0)
+		for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		addOperation(route.Path, route.Method, map[string]interface{}{
+			"summary":    "Moved to " + route.Method + " " + route.Target,
+			"tags":       []string{"legacy"},
+			"deprecated": true,
+			"parameters": params,
+			"responses": map[string]interface{}{
+				"308": map[string]interface{}{"description": "Permanent redirect to " + route.Target},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "productionSite API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		// Calls are anonymous without the token returned by POST /v1/persons/login.
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{},
+		},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// queryParams lists the fields of md which are not bound to the path or the body, skip, as query parameters.
+// Nested messages are flattened to dotted names the way grpc-gateway parses them.
+func queryParams(md protoreflect.MessageDescriptor, prefix string, skip map[string]bool, schemas map[string]interface{}) []interface{} {
+	params := make([]interface{}, 0)
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		name := prefix + string(field.Name())
+		if skip[name] {
+			continue
+		}
+		if field.Kind() == protoreflect.MessageKind {
+			switch message := field.Message(); {
+			case field.IsList() || field.IsMap() || message.FullName() == "google.protobuf.FieldMask":
+				continue
+			case !isWrapper(message) && message.FullName() != "google.protobuf.Timestamp":
+				params = append(params, queryParams(message, name+".", skip, schemas)...)
+				continue
+			}
+		}
+		params = append(params, map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": fieldSchema(field, schemas),
+		})
+	}
+	return params
+}
+
+// handlerOperation documents a hand-written route, schemas of its JSON bodies come from their Go types.
+func handlerOperation(route routeDoc, schemas map[string]interface{}) map[string]interface{} {
+	params := make([]interface{}, 0)
+	for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "integer", "format": "int32"},
+		})
+	}
+	for _, query := range route.Query {
+		params = append(params, paramSchema(query, "query"))
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(route.Status)}
+	content := make(map[string]interface{})
+	if route.Response != nil {
+		content["application/json"] = map[string]interface{}{"schema": goSchema(reflect.TypeOf(route.Response), schemas)}
+	}
+	for _, mediaType := range route.ResponseTypes {
+		content[mediaType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+	}
+	if len(content) > 0 {
+		success["content"] = content
+	}
+	op := map[string]interface{}{
+		"summary":    route.Summary,
+		"tags":       []string{route.Tag},
+		"parameters": params,
+		"responses": map[string]interface{}{
+			strconv.Itoa(route.Status): success,
+			"default":                  jsonContent("Error, plain text for malformed requests", goSchema(reflect.TypeOf(ErrorResponse{}), schemas)),
+		},
+	}
+	if route.Request != nil {
+		op["requestBody"] = requestBody(goSchema(reflect.TypeOf(route.Request), schemas))
+	}
+	if len(route.RequestTypes) > 0 {
+		body := make(map[string]interface{})
+		for _, mediaType := range route.RequestTypes {
+			body[mediaType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		op["requestBody"] = map[string]interface{}{"required": true, "content": body}
+	}
+	return op
+}
+
+func paramSchema(param paramDoc, in string) map[string]interface{} {
+	schema := map[string]interface{}{"type": param.Type}
+	if param.Format != "" {
+		schema["format"] = param.Format
+	}
+	doc := map[string]interface{}{
+		"name":     param.Name,
+		"in":       in,
+		"required": param.Required,
+		"schema":   schema,
+	}
+	if param.Description != "" {
+		doc["description"] = param.Description
+	}
+	return doc
+}
+
+// hasTimestamps reports whether messages of md can contain a timestamp, seen guards against recursive messages.
+func hasTimestamps(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) bool {
+	if md.FullName() == "google.protobuf.Timestamp" {
+		return true
+	}
+	if seen[md.FullName()] {
+		return false
+	}
+	seen[md.FullName()] = true
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if message := fields.Get(i).Message(); message != nil && hasTimestamps(message, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// goSchema returns the schema of the JSON encoding of t, named structs are added to schemas.
+func goSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return goSchema(t.Elem(), schemas)
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": goSchema(t.Elem(), schemas)}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Struct:
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		properties := make(map[string]interface{})
+		schemas[t.Name()] = map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = goSchema(field.Type, schemas)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+func httpRulePattern(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	}
+	return "", ""
+}
+
+func jsonContent(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func requestBody(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func isWrapper(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Path() == "google/protobuf/wrappers.proto"
+}
+
+// messageSchema returns a reference to the schema of md, adding it and its dependencies to schemas.
+// Well-known types are inlined the way protojson encodes them.
+func messageSchema(md protoreflect.MessageDescriptor, schemas map[string]interface{}) map[string]interface{} {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case "google.protobuf.Empty":
+		return map[string]interface{}{"type": "object"}
+	case "google.protobuf.FieldMask":
+		return map[string]interface{}{"type": "string"}
+	}
+	if isWrapper(md) {
+		schema := fieldSchema(md.Fields().ByName("value"), schemas)
+		schema["nullable"] = true
+		return schema
+	}
+
+	name := string(md.Name())
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	if _, ok := schemas[name]; ok {
+		return ref
+	}
+	properties := make(map[string]interface{})
+	schemas[name] = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		properties[string(field.Name())] = fieldSchema(field, schemas)
+	}
+	return ref
+}
+
+func fieldSchema(fd protoreflect.FieldDescriptor, schemas map[string]interface{}) map[string]interface{} {
+	var schema map[string]interface{}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		schema = map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		schema = map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema = map[string]interface{}{"type": "integer", "format": "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		schema = map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		schema = map[string]interface{}{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		schema = map[string]interface{}{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		schema = map[string]interface{}{"type": "string"}
+	case protoreflect.BytesKind:
+		schema = map[string]interface{}{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		schema = map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		schema = messageSchema(fd.Message(), schemas)
+	}
+	if fd.IsList() {
+		return map[string]interface{}{"type": "array", "items": schema}
+	}
+	return schema
+}
+
+func openAPIHandler(document []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(document)
+	}
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>productionSite API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "` + openAPIDocument + `", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
+
+func marshalOpenAPI() ([]byte, error) {
+	return json.MarshalIndent(buildOpenAPI(), "", "  ")
+}
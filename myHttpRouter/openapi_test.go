@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// generatedSwaggers are written by protoc-gen-openapiv2 from the google.api.http annotations of the gateway services.
+var generatedSwaggers = []string{
+	"../course/grpc/pkg/dish_v1/dish.swagger.json",
+	"../course/grpc/pkg/reservation_v1/reservation.swagger.json",
+}
+
+type document struct {
+	Paths map[string]map[string]struct {
+		OperationID string `json:"operationId"`
+		Parameters  []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"paths"`
+}
+
+// servedDocument builds the router and fetches /openapi.json from it.
+func servedDocument(t *testing.T) (*chi.Mux, document) {
+	t.Helper()
+	r, err := newRouter(http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("newRouter: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, openAPIDocument, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d", openAPIDocument, rec.Code)
+	}
+	var served document
+	if err = json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("decode served document: %v", err)
+	}
+	return r, served
+}
+
+func operations(doc document, keep func(path string) bool) map[string]bool {
+	ops := make(map[string]bool)
+	for path, methods := range doc.Paths {
+		if !keep(path) {
+			continue
+		}
+		for method := range methods {
+			ops[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return ops
+}
+
+func compare(t *testing.T, want, got map[string]bool, missing, extra string) {
+	t.Helper()
+	var drift []string
+	for op := range want {
+		if !got[op] {
+			drift = append(drift, missing+" "+op)
+		}
+	}
+	for op := range got {
+		if !want[op] {
+			drift = append(drift, extra+" "+op)
+		}
+	}
+	sort.Strings(drift)
+	for _, d := range drift {
+		t.Error(d)
+	}
+}
+
+func isREST(path string) bool {
+	return strings.HasPrefix(path, restPrefix+"/")
+}
+
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	r, served := servedDocument(t)
+
+	registered := make(map[string]bool)
+	err := chi.Walk(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if isREST(route) || route == openAPIDocument || route == swaggerUI {
+			return nil
+		}
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk router: %v", err)
+	}
+	documented := operations(served, func(path string) bool { return !isREST(path) })
+
+	compare(t, registered, documented, "undocumented route", "documented route is not registered")
+}
+
+func isGatewayParam(name string) bool {
+	for _, param := range gatewayParams {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOpenAPIMatchesGeneratedSwagger(t *testing.T) {
+	_, served := servedDocument(t)
+
+	var generated document
+	for _, name := range generatedSwaggers {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read generated swagger: %v", err)
+		}
+		if err = json.Unmarshal(data, &generated); err != nil {
+			t.Fatalf("decode generated swagger %s: %v", name, err)
+		}
+	}
+
+	compare(t, operations(generated, isREST), operations(served, isREST), "missing operation", "operation is not in the generated swagger")
+
+	for path, methods := range generated.Paths {
+		for method, want := range methods {
+			got, ok := served.Paths[path][method]
+			if !ok {
+				continue
+			}
+			if got.OperationID != want.OperationID {
+				t.Errorf("%s %s: operationId %q, generated %q", method, path, got.OperationID, want.OperationID)
+			}
+			wantParams := make(map[string]bool)
+			for _, p := range want.Parameters {
+				if p.In != "body" {
+					wantParams[p.In+" "+p.Name] = true
+				}
+			}
+			gotParams := make(map[string]bool)
+			for _, p := range got.Parameters {
+				if !isGatewayParam(p.Name) {
+					gotParams[p.In+" "+p.Name] = true
+				}
+			}
+			compare(t, wantParams, gotParams, method+" "+path+": missing parameter", method+" "+path+": parameter is not in the generated swagger")
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-chi/chi"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paramError describes a malformed path or query parameter.
+type paramError struct {
+	Name   string
+	Reason string
+}
+
+func (e *paramError) Error() string {
+	return fmt.Sprintf("invalid parameter %s: %s", e.Name, e.Reason)
+}
+
+// parseInt32 parses a base 10 value in [min, math.MaxInt32].
+func parseInt32(name, value string, min int64) (int32, error) {
+	if value == "" {
+		return 0, &paramError{Name: name, Reason: "is required"}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, &paramError{Name: name, Reason: "must be an integer"}
+	}
+	if n < min || n > math.MaxInt32 {
+		return 0, &paramError{Name: name, Reason: fmt.Sprintf("must be between %d and %d", min, math.MaxInt32)}
+	}
+	return int32(n), nil
+}
+
+// writeParamError writes a 400 JSON error for a malformed parameter.
+func writeParamError(w http.ResponseWriter, err error) {
+	response := ErrorResponse{Error: err.Error()}
+	if pe, ok := err.(*paramError); ok {
+		response.Error = "invalid parameter"
+		response.FieldViolations = []FieldViolation{{Field: pe.Name, Description: pe.Reason}}
+	}
+	writeJSONError(w, http.StatusBadRequest, response)
+}
+
+// urlParamID reads a non-negative int32 chi url parameter, on failure writes 400 and returns false.
+func urlParamID(w http.ResponseWriter, r *http.Request, name string) (int32, bool) {
+	id, err := parseInt32(name, chi.URLParam(r, name), 0)
+	if err != nil {
+		writeParamError(w, err)
+		return 0, false
+	}
+	return id, true
+}
+
+// queryParamInt32 reads an optional int32 query parameter not less than min.
+// set is false when the parameter is absent, ok is false when a 400 has been written.
+func queryParamInt32(w http.ResponseWriter, r *http.Request, name string, min int64) (value int32, set bool, ok bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, false, true
+	}
+	value, err := parseInt32(name, raw, min)
+	if err != nil {
+		writeParamError(w, err)
+		return 0, false, false
+	}
+	return value, true, true
+}
+
+// queryParamBool reads an optional boolean query parameter, absent means false.
+func queryParamBool(w http.ResponseWriter, r *http.Request, name string) (bool, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, true
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeParamError(w, &paramError{Name: name, Reason: "must be true or false"})
+		return false, false
+	}
+	return value, true
+}
+
+// queryParamLocation reads the optional tz query parameter, an IANA time zone such as Europe/Moscow
+// in which timestamps of the response are shown. Absent means UTC.
+func queryParamLocation(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return time.UTC, true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		writeParamError(w, &paramError{Name: "tz", Reason: "must be an IANA time zone name"})
+		return nil, false
+	}
+	return loc, true
+}
+
+// versionETag formats a dish version as a strong entity tag.
+func versionETag(version int32) string {
+	return strconv.Quote(strconv.Itoa(int(version)))
+}
+
+// ifMatchVersion reads the version expected by an If-Match header.
+// A missing header or "*" means any version, a malformed one writes 400 and returns false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (*wrapperspb.Int32Value, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err == nil {
+		var version int32
+		version, err = parseInt32("If-Match", tag, 1)
+		if err == nil {
+			return wrapperspb.Int32(version), true
+		}
+	}
+	writeParamError(w, &paramError{Name: "If-Match", Reason: "must be a quoted dish version"})
+	return nil, false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	desc "github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dish_v1"
+	"github.com/RikiTikiTavee17/productionSite/course/grpc/pkg/dishsheet"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxSpreadsheetSize bounds the body of an import.
+const maxSpreadsheetSize = 10 << 20
+
+const (
+	importDishes = "/dishes/import"
+	exportDishes = "/dishes/export"
+)
+
+// spreadsheetFormat reads the format query parameter, falling back to the Content-Type of the body.
+func spreadsheetFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = dishsheet.FormatCSV
+		if strings.HasPrefix(r.Header.Get("Content-Type"), dishsheet.ContentTypes[dishsheet.FormatXLSX]) {
+			format = dishsheet.FormatXLSX
+		}
+	}
+	if _, ok := dishsheet.ContentTypes[format]; !ok {
+		writeParamError(w, &paramError{Name: "format", Reason: "must be csv or xlsx"})
+		return "", false
+	}
+	return format, true
+}
+
+func importDishesHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := spreadsheetFormat(w, r)
+	if !ok {
+		return
+	}
+	dryRun, ok := queryParamBool(w, r, "dry_run")
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpreadsheetSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Spreadsheet is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read spreadsheet", http.StatusBadRequest)
+		return
+	}
+	rows, rowErrors, err := dishsheet.Read(format, data)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx, cancel := grpcContext(r)
+	defer cancel()
+
+	report, err := dishsheet.Import(ctx, dishClient, rows, rowErrors, dryRun)
+	if err != nil {
+		grpcError(w, err, "Failed to import dishes")
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	switch {
+	case len(report.Errors) > 0 && !dryRun:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case len(report.Created) > 0:
+		w.WriteHeader(http.StatusCreated)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode import report", http.StatusInternalServerError)
+		return
+	}
+}
+
+func exportDishesHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := spreadsheetFormat(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := grpcContext(r)
+	defer cancel()
+
+	grpcRes, err := dishClient.List(ctx, &desc.ListRequest{})
+	if err != nil {
+		grpcError(w, err, "Failed to list dishes")
+		return
+	}
+	dishes := make([]*desc.DishInfo, 0, len(grpcRes.GetDishes()))
+	for _, dish := range grpcRes.GetDishes() {
+		dishes = append(dishes, dish.GetInfo())
+	}
+	data, err := dishsheet.Write(format, dishes)
+	if err != nil {
+		http.Error(w, "Failed to encode dishes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", dishsheet.ContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="dishes.`+format+`"`)
+	w.Write(data)
+}